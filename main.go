@@ -1,21 +1,62 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
 	server "rek/src"
 	"strings" 
 )
 
 func main() {
+	dbPath := flag.String("db", "./rek.db", "chemin de la base SQLite")
+	migrateOnly := flag.Bool("migrate-only", false, "applique les migrations puis quitte")
+	migrateStatus := flag.Bool("migrate-status", false, "affiche l'état des migrations puis quitte")
+	mediaDir := flag.String("media-dir", "", "dossier de la bibliothèque audio locale (Blindtest hors ligne)")
+	dictDir := flag.String("dict-dir", "", "dossier des listes de mots du Petit Bac (validation automatique)")
+	deezerAPI := flag.String("deezer-api", "", "adresse de l'API Deezer (par défaut https://api.deezer.com)")
+	flag.Parse()
+
+	if *migrateStatus {
+		printMigrationStatus(*dbPath)
+		return
+	}
+
 	// Initialiser la base de données
-	db, err := server.InitDB("./rek.db")
+	db, err := server.InitDB(*dbPath)
 	if err != nil {
 		log.Fatalf("Échec de l'initialisation de la base de données : %v", err)
 	}
 	defer db.Close()
 	log.Println("Base de données initialisée avec succès.")
 
+	if *migrateOnly {
+		log.Println("Migrations à jour, arrêt (-migrate-only).")
+		return
+	}
+
+	if err := server.SetLocalLibraryDir(*mediaDir); err != nil {
+		log.Fatalf("Bibliothèque locale invalide : %v", err)
+	}
+	if *deezerAPI != "" {
+		server.SetDeezerBaseURL(*deezerAPI)
+		log.Printf("API Deezer : %s", *deezerAPI)
+	}
+	if *mediaDir != "" {
+		log.Printf("Bibliothèque locale activée : %s", *mediaDir)
+	}
+	if n, err := server.SetPetitBacDictionaryDir(*dictDir); err != nil {
+		log.Fatalf("Listes de mots invalides : %v", err)
+	} else if *dictDir != "" {
+		log.Printf("Listes de mots du Petit Bac : %d catégorie(s) dans %s", n, *dictDir)
+	}
+
+	// Nettoyage des sessions expirées en arrière-plan
+	server.StartSessionSweeper(context.Background(), 0)
+
 	http.HandleFunc("/", server.HomeHandler)
 	http.HandleFunc("/register", server.RegisterHandler)
 	http.HandleFunc("/connexion", server.ConnexionHandler)
@@ -36,6 +77,44 @@ func main() {
 		}
 		server.AfficherSalleHandler(w, r)
 	})))
+	http.Handle("/profil/", server.RequireAuth(http.HandlerFunc(server.ProfilHandler)))
+	http.Handle("/api/profil/", server.RequireAuth(http.HandlerFunc(server.APIProfilHandler)))
+	http.Handle("/classement", server.RequireAuth(http.HandlerFunc(server.ClassementHandler)))
+	http.Handle("/api/classement", server.RequireAuth(http.HandlerFunc(server.APIClassementHandler)))
+	http.Handle("/playlists", server.RequireAuth(http.HandlerFunc(server.PlaylistsHandler)))
+	http.Handle("/playlists/", server.RequireAuth(http.HandlerFunc(server.PlaylistsHandler)))
+	http.Handle("/api/playlists", server.RequireAuth(http.HandlerFunc(server.APIPlaylistsHandler)))
+	http.Handle("/api/playlists/", server.RequireAuth(http.HandlerFunc(server.APIPlaylistsHandler)))
+	http.Handle("/api/deezer/", server.RequireAuth(http.HandlerFunc(server.APIDeezerHandler)))
+	http.Handle("/api/presets/petitbac", server.RequireAuth(http.HandlerFunc(server.APIPetitBacPresetsHandler)))
+	http.Handle("/api/presets/petitbac/", server.RequireAuth(http.HandlerFunc(server.APIPetitBacPresetsHandler)))
+	http.Handle("/media/", server.RequireAuth(http.HandlerFunc(server.MediaHandler)))
 	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("./static/"))))
 	http.ListenAndServe(":8080", nil)
 }
+
+// printMigrationStatus affiche les migrations appliquées / en attente sans rien modifier
+func printMigrationStatus(dbPath string) {
+	db, err := server.OpenDB(dbPath)
+	if err != nil {
+		log.Fatalf("Échec de l'ouverture de la base de données : %v", err)
+	}
+	defer db.Close()
+
+	statuses, err := server.GetMigrationStatus(context.Background(), db)
+	if err != nil {
+		log.Fatalf("Lecture des migrations impossible : %v", err)
+	}
+	pending := 0
+	for _, st := range statuses {
+		if st.Applied {
+			fmt.Printf("%04d_%-30s appliquée le %s\n", st.Version, st.Name, st.AppliedAt.Format("2006-01-02 15:04:05"))
+			continue
+		}
+		pending++
+		fmt.Printf("%04d_%-30s EN ATTENTE\n", st.Version, st.Name)
+	}
+	if pending > 0 {
+		os.Exit(1)
+	}
+}
@@ -31,6 +31,24 @@ func AfficherSalleHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// /salle/{code}/ready
+	if len(parts) >= 2 && parts[1] == "ready" {
+		PretSalleHandler(w, r, code)
+		return
+	}
+
+	// /salle/{code}/admin
+	if len(parts) >= 2 && parts[1] == "admin" {
+		TransfererAdminSalleHandler(w, r, code)
+		return
+	}
+
+	// /salle/{code}/kick
+	if len(parts) >= 2 && parts[1] == "kick" {
+		ExclureSalleHandler(w, r, code)
+		return
+	}
+
 	// /salle/{code}/leave
 	if len(parts) >= 2 && parts[1] == "leave" {
 		QuitterSalleHandler(w, r, code)
@@ -72,25 +90,33 @@ func AfficherSalleHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	label := "Salle"
-	switch room.Type {
-	case RoomTypeBlindTest:
-		label = "Blind Test"
-	case RoomTypePetitBac:
-		label = "Petit Bac"
+	var isReady bool
+	for _, p := range players {
+		if p.UserID == userID {
+			isReady = p.IsReady
+		}
+	}
+	readyStatus := computeReadyStatus(room, players, false)
+	var countdownUnix int64
+	if t := ReadyCountdownEndsAt(room.ID); !t.IsZero() {
+		countdownUnix = t.Unix()
 	}
 
+	label := room.Type.Label()
+
 	// Charger config spécifique
 	var playlist string
+	attempts := defaultBlindtestAttempts
 	var categories []PetitBacCategory
 
 	switch room.Type {
 	case RoomTypeBlindTest:
-		if p, ok, err := GetBlindtestPlaylist(r.Context(), room.ID); err != nil {
+		if s, ok, err := GetBlindtestSettings(r.Context(), room.ID); err != nil {
 			http.Error(w, "Erreur lors du chargement de la configuration.", http.StatusInternalServerError)
 			return
 		} else if ok {
-			playlist = p
+			playlist = s.Playlist
+			attempts = s.MaxAttempts
 		}
 	case RoomTypePetitBac:
 		cats, err := ListPetitBacCategories(r.Context(), room.ID)
@@ -106,8 +132,13 @@ func AfficherSalleHandler(w http.ResponseWriter, r *http.Request) {
 		Players:   players,
 		GameLabel: label,
 		IsAdmin:   isAdmin,
+		IsReady:   isReady,
+
+		Ready:              readyStatus,
+		ReadyCountdownUnix: countdownUnix,
 
 		BlindtestPlaylist:  playlist,
+		BlindtestAttempts:  attempts,
 		PetitBacCategories: categories,
 	})
 }
\ No newline at end of file
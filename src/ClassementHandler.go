@@ -0,0 +1,99 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ClassementPageData contient les données de la page /classement
+type ClassementPageData struct {
+	Board  *Leaderboard
+	Genres []string
+	Prev   string // liens de pagination (vides s'il n'y a pas de page)
+	Next   string
+}
+
+func leaderboardQueryFromRequest(r *http.Request) LeaderboardQuery {
+	v := r.URL.Query()
+	page, _ := strconv.Atoi(v.Get("page"))
+	perPage, _ := strconv.Atoi(v.Get("per_page"))
+	return LeaderboardQuery{
+		Period:  v.Get("period"),
+		Type:    RoomType(v.Get("type")),
+		Genre:   v.Get("genre"),
+		Page:    page,
+		PerPage: perPage,
+	}
+}
+
+func loadLeaderboard(w http.ResponseWriter, r *http.Request) (*Leaderboard, bool) {
+	lb, err := GetLeaderboard(r.Context(), leaderboardQueryFromRequest(r))
+	if err != nil {
+		if errors.Is(err, ErrInvalidLeaderboard) {
+			http.Error(w, "Filtre de classement invalide.", http.StatusBadRequest)
+			return nil, false
+		}
+		log.Printf("Classement : %v", err)
+		http.Error(w, "Erreur lors du chargement du classement.", http.StatusInternalServerError)
+		return nil, false
+	}
+	return lb, true
+}
+
+// le ClassementHandler affiche les classements globaux (tous temps, semaine, par jeu, par genre)
+
+func ClassementHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Méthode non autorisée.", http.StatusMethodNotAllowed)
+		return
+	}
+
+	lb, ok := loadLeaderboard(w, r)
+	if !ok {
+		return
+	}
+	genres, err := ListPlayedGenres(r.Context())
+	if err != nil {
+		log.Printf("Classement (genres) : %v", err)
+	}
+
+	data := ClassementPageData{Board: lb, Genres: genres}
+	pageLink := func(page int) string {
+		v := url.Values{}
+		v.Set("period", lb.Period)
+		if lb.Type != "" {
+			v.Set("type", string(lb.Type))
+		}
+		if lb.Genre != "" {
+			v.Set("genre", lb.Genre)
+		}
+		if lb.PerPage != defaultLeaderboardPerPage {
+			v.Set("per_page", strconv.Itoa(lb.PerPage))
+		}
+		v.Set("page", strconv.Itoa(page))
+		return "/classement?" + v.Encode()
+	}
+	if lb.Page > 1 {
+		data.Prev = pageLink(lb.Page - 1)
+	}
+	if lb.Page < lb.Pages {
+		data.Next = pageLink(lb.Page + 1)
+	}
+	renderTemplate(w, "classement.html", data)
+}
+
+// APIClassementHandler renvoie le même classement en JSON (/api/classement?period=&type=&genre=&page=&per_page=)
+func APIClassementHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Méthode non autorisée.", http.StatusMethodNotAllowed)
+		return
+	}
+	lb, ok := loadLeaderboard(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, lb)
+}
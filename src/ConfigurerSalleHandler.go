@@ -1,10 +1,15 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 )
 
 
@@ -13,7 +18,16 @@ type SalleConfigPageData struct {
 	GameLabel          string
 	Error              string
 	BlindtestPlaylist  string
+	BlindtestSource    BlindtestSource
+	BlindtestAttempts  int
+	BlindtestScoring   BlindtestScoring
 	PetitBacCategories []PetitBacCategory
+	PetitBacSettings   PetitBacSettings
+	PetitBacPresets    []PetitBacPreset
+	UserPlaylists      []Playlist    // playlists de l'admin et celles partagées avec lui
+	DeezerGenres       []DeezerGenre // catalogue Deezer, vide si injoignable
+	LocalLibrary       bool
+	Dictionary         bool // listes de mots du Petit Bac chargées (-dict-dir)
 }
 
 func ConfigurerSalleHandler(w http.ResponseWriter, r *http.Request, code string) {
@@ -42,56 +56,120 @@ func ConfigurerSalleHandler(w http.ResponseWriter, r *http.Request, code string)
 		return
 	}
 
-	label := "Salle"
-	switch room.Type {
-	case RoomTypeBlindTest:
-		label = "Blind Test"
-	case RoomTypePetitBac:
-		label = "Petit Bac"
+	label := room.Type.Label()
+
+	// Section commune : ready-check (formulaire séparé, identifié par section=ready)
+	if r.Method == http.MethodPost && r.FormValue("section") == "ready" {
+		quorum, err := strconv.Atoi(strings.TrimSpace(r.FormValue("ready_quorum")))
+		if err != nil {
+			quorum = 0
+		}
+		requireReady := r.FormValue("require_ready") == "on"
+		autoStart := r.FormValue("auto_start") == "on"
+		if err := UpdateRoomReadySettings(r.Context(), room.ID, requireReady, quorum, autoStart); err != nil {
+			if errors.Is(err, ErrInvalidReadySettings) {
+				http.Error(w, fmt.Sprintf("Quorum invalide (entre %d et %d %%).", minReadyQuorum, maxReadyQuorum), http.StatusBadRequest)
+				return
+			}
+			http.Error(w, "Erreur lors de l'enregistrement.", http.StatusInternalServerError)
+			return
+		}
+		BroadcastRoomUpdated(room.ID)
+		CheckReadyState(room.ID)
+		http.Redirect(w, r, "/salle/"+room.Code+"/config", http.StatusSeeOther)
+		return
 	}
 
 	switch room.Type {
 	case RoomTypeBlindTest:
+		renderBlindtest := func(msg string, settings BlindtestSettings) {
+			playlists, _ := ListPlaylists(r.Context(), userID)
+			renderTemplate(w, "config_salle.html", SalleConfigPageData{
+				Room:              room,
+				GameLabel:         label,
+				Error:             msg,
+				BlindtestPlaylist: settings.Playlist,
+				BlindtestSource:   settings.Source,
+				BlindtestAttempts: settings.MaxAttempts,
+				BlindtestScoring:  settings.Scoring,
+				DeezerGenres:      deezerGenresForPage(r),
+				UserPlaylists:     playlists,
+				LocalLibrary:      LocalLibraryAvailable(),
+			})
+		}
+
 		if r.Method == http.MethodPost {
 			if err := r.ParseForm(); err != nil {
 				http.Error(w, "Formulaire invalide.", http.StatusBadRequest)
 				return
 			}
-			playlist := strings.TrimSpace(r.FormValue("playlist"))
-			switch strings.ToLower(playlist) {
-			case "rock":
-				playlist = "Rock"
-			case "rap":
-				playlist = "Rap"
-			case "pop":
-				playlist = "Pop"
-			default:
-				renderTemplate(w, "config_salle.html", SalleConfigPageData{
-					Room:              room,
-					GameLabel:         label,
-					Error:             "Playlist invalide (Rock, Rap, Pop).",
-					BlindtestPlaylist: playlist,
-				})
+			settings := BlindtestSettings{MaxAttempts: defaultBlindtestAttempts, Scoring: parseBlindtestScoring(r)}
+			src, err := parseBlindtestSourceForm(r)
+			settings.Source = src
+			if err != nil {
+				renderBlindtest("Source invalide : choisis un type puis un mot-clé, un identifiant ou un lien Deezer.", settings)
+				return
+			}
+			attempts, err := strconv.Atoi(strings.TrimSpace(r.FormValue("max_attempts")))
+			if err != nil || attempts < minBlindtestAttempts || attempts > maxBlindtestAttempts {
+				renderBlindtest(fmt.Sprintf("Nombre d'essais invalide (entre %d et %d).", minBlindtestAttempts, maxBlindtestAttempts), settings)
+				return
+			}
+			settings.MaxAttempts = attempts
+			if src.Kind == SourceLocal && !LocalLibraryAvailable() {
+				renderBlindtest("Bibliothèque locale non configurée (lancer le serveur avec -media-dir).", settings)
+				return
+			}
+			if src.Kind == SourceUser {
+				// l'admin doit pouvoir lire la playlist (la sienne ou partagée avec lui)
+				playlistID, _ := strconv.Atoi(src.ID)
+				p, err := GetPlaylist(r.Context(), userID, playlistID)
+				if err != nil {
+					_, msg := playlistError(err)
+					renderBlindtest(msg, settings)
+					return
+				}
+				src.Label = p.Name
+			}
+			// vérifie que la playlist / l'album / l'artiste existe et récupère son nom
+			if src, err = ResolveBlindtestSource(r.Context(), src); err != nil {
+				log.Printf("Source Blindtest %s:%s : %v", src.Kind, src.ID, err)
+				renderBlindtest(deezerErrorMessage(err), settings)
 				return
 			}
-			if err := SetBlindtestPlaylist(r.Context(), room.ID, playlist); err != nil {
+			settings.Source = src
+			if err := SaveBlindtestSettings(r.Context(), room.ID, settings); err != nil {
+				if errors.Is(err, ErrInvalidBlindtestSettings) {
+					renderBlindtest(fmt.Sprintf("Barème invalide (bonus du premier 0-%d, série 0-%d %%, minimum 0-%d, pénalité 0-%d).", maxFirstBonus, maxStreakPct, maxMinPoints, maxWrongPenalty), settings)
+					return
+				}
 				http.Error(w, "Erreur lors de l'enregistrement.", http.StatusInternalServerError)
 				return
 			}
+			PrefetchBlindtestSource(settings.Source)
 			BroadcastRoomUpdated(room.ID)
 			http.Redirect(w, r, "/salle/"+room.Code, http.StatusSeeOther)
 			return
 		}
 
-		playlist, _, _ := GetBlindtestPlaylist(r.Context(), room.ID)
-		renderTemplate(w, "config_salle.html", SalleConfigPageData{
-			Room:              room,
-			GameLabel:         label,
-			BlindtestPlaylist: playlist,
-		})
+		settings, _, _ := GetBlindtestSettings(r.Context(), room.ID)
+		renderBlindtest("", settings)
 		return
 
 	case RoomTypePetitBac:
+		renderPetitBac := func(msg string, cats []PetitBacCategory, settings PetitBacSettings) {
+			presets, _ := ListPetitBacPresets(r.Context(), userID)
+			renderTemplate(w, "config_salle.html", SalleConfigPageData{
+				Room:               room,
+				GameLabel:          label,
+				Error:              msg,
+				PetitBacCategories: cats,
+				PetitBacSettings:   settings,
+				PetitBacPresets:    presets,
+				Dictionary:         PetitBacDictionaryAvailable(),
+			})
+		}
+
 		if err := EnsureDefaultPetitBacCategories(r.Context(), room.ID); err != nil {
 			http.Error(w, "Erreur lors de l'initialisation des catégories.", http.StatusInternalServerError)
 			return
@@ -109,20 +187,62 @@ func ConfigurerSalleHandler(w http.ResponseWriter, r *http.Request, code string)
 				return
 			}
 
+			// Modèles de catégories (sections preset_*)
+			if section := r.FormValue("section"); strings.HasPrefix(section, "preset_") {
+				if err := handlePetitBacPresetForm(r, room.ID, userID, section); err != nil {
+					status, msg := petitBacPresetError(err)
+					if status == http.StatusInternalServerError {
+						http.Error(w, msg, status)
+						return
+					}
+					settings, _ := GetPetitBacSettings(r.Context(), room.ID)
+					renderPetitBac(msg, cats, settings)
+					return
+				}
+				BroadcastRoomUpdated(room.ID)
+				http.Redirect(w, r, "/salle/"+room.Code+"/config", http.StatusSeeOther)
+				return
+			}
+
+			// Barème et règles de vote (formulaire séparé, identifié par section=petitbac_rules)
+			if r.FormValue("section") == "petitbac_rules" {
+				settings := parsePetitBacSettings(r)
+				if err := SavePetitBacSettings(r.Context(), room.ID, settings); err != nil {
+					if errors.Is(err, ErrInvalidPetitBacSettings) {
+						renderPetitBac(fmt.Sprintf("Règles invalides (points entre %d et %d, délai après STOP entre 0 et %d s, au moins une lettre à tirer).", minPetitBacPoints, maxPetitBacPoints, maxStopGrace), cats, settings)
+						return
+					}
+					http.Error(w, "Erreur lors de l'enregistrement.", http.StatusInternalServerError)
+					return
+				}
+				BroadcastRoomUpdated(room.ID)
+				http.Redirect(w, r, "/salle/"+room.Code+"/config", http.StatusSeeOther)
+				return
+			}
+
+			// liste complète envoyée d'un bloc : renommages, suppressions et ajout dans une seule transaction
+			list := make([]PetitBacCategory, 0, len(cats)+1)
 			for _, c := range cats {
 				if r.FormValue("delete_"+strconv.Itoa(c.ID)) == "on" {
-					_ = DeletePetitBacCategory(r.Context(), room.ID, c.ID)
 					continue
 				}
-				newName := strings.TrimSpace(r.FormValue("name_" + strconv.Itoa(c.ID)))
-				if newName != "" && newName != c.Name {
-					_ = UpdatePetitBacCategoryName(r.Context(), room.ID, c.ID, newName)
+				if newName := strings.TrimSpace(r.FormValue("name_" + strconv.Itoa(c.ID))); newName != "" {
+					c.Name = newName
 				}
+				list = append(list, c)
 			}
-
-			newCat := strings.TrimSpace(r.FormValue("new_category"))
-			if newCat != "" {
-				_ = AddPetitBacCategory(r.Context(), room.ID, newCat)
+			if newCat := strings.TrimSpace(r.FormValue("new_category")); newCat != "" {
+				list = append(list, PetitBacCategory{Name: newCat})
+			}
+			if _, err := ReplacePetitBacCategories(r.Context(), room.ID, list); err != nil {
+				status, msg := petitBacCategoriesError(err)
+				if status == http.StatusInternalServerError {
+					http.Error(w, msg, status)
+					return
+				}
+				settings, _ := GetPetitBacSettings(r.Context(), room.ID)
+				renderPetitBac(msg, cats, settings)
+				return
 			}
 
 			BroadcastRoomUpdated(room.ID)
@@ -135,11 +255,12 @@ func ConfigurerSalleHandler(w http.ResponseWriter, r *http.Request, code string)
 			http.Error(w, "Erreur lors du chargement.", http.StatusInternalServerError)
 			return
 		}
-		renderTemplate(w, "config_salle.html", SalleConfigPageData{
-			Room:               room,
-			GameLabel:          label,
-			PetitBacCategories: cats,
-		})
+		settings, err := GetPetitBacSettings(r.Context(), room.ID)
+		if err != nil {
+			http.Error(w, "Erreur lors du chargement.", http.StatusInternalServerError)
+			return
+		}
+		renderPetitBac("", cats, settings)
 		return
 	default:
 		http.Error(w, "Type de salle invalide.", http.StatusBadRequest)
@@ -147,3 +268,107 @@ func ConfigurerSalleHandler(w http.ResponseWriter, r *http.Request, code string)
 	}
 }
 
+// handlePetitBacPresetForm traite les formulaires de modèles : appliquer, enregistrer la salle,
+// importer un JSON exporté ou supprimer un modèle du joueur
+func handlePetitBacPresetForm(r *http.Request, roomID, userID int, section string) error {
+	presetID, _ := strconv.Atoi(r.FormValue("preset_id"))
+	switch section {
+	case "preset_apply":
+		return ApplyPetitBacPreset(r.Context(), roomID, userID, presetID)
+	case "preset_save":
+		_, err := SaveRoomAsPetitBacPreset(r.Context(), roomID, userID, r.FormValue("preset_name"))
+		return err
+	case "preset_import":
+		var p PetitBacPreset
+		if err := json.Unmarshal([]byte(r.FormValue("preset_json")), &p); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPreset, err)
+		}
+		_, err := CreatePetitBacPreset(r.Context(), userID, p)
+		return err
+	case "preset_delete":
+		return DeletePetitBacPreset(r.Context(), userID, presetID)
+	}
+	return ErrPresetNotFound
+}
+
+// parseBlindtestSourceForm lit la source choisie ; sans source_kind, l'ancien champ playlist
+// (Rock, Rap, Pop, Local) reste accepté
+func parseBlindtestSourceForm(r *http.Request) (BlindtestSource, error) {
+	kind := strings.TrimSpace(r.FormValue("source_kind"))
+	if kind == "" {
+		playlist := strings.TrimSpace(r.FormValue("playlist"))
+		if playlist == "" {
+			return BlindtestSource{}, ErrInvalidBlindtestSource
+		}
+		return legacyBlindtestSource(playlist), nil
+	}
+	ref := r.FormValue("source_ref")
+	if kind == SourceGenre && strings.TrimSpace(r.FormValue("genre_id")) != "" {
+		ref = r.FormValue("genre_id")
+	}
+	if kind == SourceUser {
+		ref = r.FormValue("user_playlist_id")
+	}
+	return ParseBlindtestSource(kind, ref)
+}
+
+// deezerGenresForPage charge le catalogue des genres sans bloquer la page si Deezer ne répond pas
+func deezerGenresForPage(r *http.Request) []DeezerGenre {
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+	genres, err := ListDeezerGenres(ctx)
+	if err != nil {
+		log.Printf("Catalogue des genres Deezer : %v", err)
+		return nil
+	}
+	return genres
+}
+
+// parseBlindtestScoring lit le barème du formulaire : champ vide = option désactivée,
+// valeur illisible = -1 pour être refusée par la validation
+func parseBlindtestScoring(r *http.Request) BlindtestScoring {
+	field := func(name string) int {
+		v := strings.TrimSpace(r.FormValue(name))
+		if v == "" {
+			return 0
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return -1
+		}
+		return n
+	}
+	return BlindtestScoring{
+		FirstBonus:   field("first_bonus"),
+		StreakPct:    field("streak_bonus_pct"),
+		MinPoints:    field("min_points"),
+		WrongPenalty: field("wrong_penalty"),
+	}
+}
+
+// parsePetitBacSettings lit les règles du Petit Bac ; un nombre illisible sort des bornes pour être refusé
+func parsePetitBacSettings(r *http.Request) PetitBacSettings {
+	field := func(name string) int {
+		n, err := strconv.Atoi(strings.TrimSpace(r.FormValue(name)))
+		if err != nil {
+			return minPetitBacPoints - 1
+		}
+		return n
+	}
+	seed, _ := strconv.ParseInt(strings.TrimSpace(r.FormValue("letter_seed")), 10, 64)
+	return PetitBacSettings{
+		PointsUnique:        field("points_unique"),
+		PointsShared:        field("points_shared"),
+		PointsInvalid:       field("points_invalid"),
+		VoteThreshold:       r.FormValue("vote_threshold"),
+		SelfVote:            r.FormValue("self_vote") == "on",
+		NormalizeDuplicates: r.FormValue("normalize_duplicates") == "on",
+		EndMode:             r.FormValue("end_mode"),
+		StopGrace:           field("stop_grace"),
+		ExcludedLetters:     r.FormValue("excluded_letters"),
+		HardLetters:         r.FormValue("hard_letters") == "on",
+		LetterPool:          r.FormValue("letter_pool"),
+		LetterSeed:          seed,
+		DictionaryCheck:     r.FormValue("dictionary_check") == "on",
+	}
+}
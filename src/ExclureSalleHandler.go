@@ -0,0 +1,72 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// le ExclureSalleHandler permet à l'admin d'exclure (ban=1 : bannir) un joueur de la salle
+
+func ExclureSalleHandler(w http.ResponseWriter, r *http.Request, code string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Méthode non autorisée.", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, err := GetSessionUserID(r)
+	if err != nil {
+		http.Redirect(w, r, "/connexion", http.StatusSeeOther)
+		return
+	}
+
+	room, err := GetRoomByCode(r.Context(), code)
+	if err != nil {
+		if errors.Is(err, ErrRoomNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, "Erreur room.", http.StatusInternalServerError)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Formulaire invalide.", http.StatusBadRequest)
+		return
+	}
+	targetID, err := strconv.Atoi(strings.TrimSpace(r.FormValue("user_id")))
+	if err != nil || targetID <= 0 {
+		http.Error(w, "Joueur invalide.", http.StatusBadRequest)
+		return
+	}
+	ban := r.FormValue("ban") == "1"
+
+	var pseudo string
+	_ = Rekdb.QueryRowContext(r.Context(), SQLSelectUserPseudoByID, targetID).Scan(&pseudo)
+
+	if err := KickRoomPlayer(r.Context(), room.ID, userID, targetID, ban); err != nil {
+		switch {
+		case errors.Is(err, ErrNotRoomAdmin):
+			http.Error(w, "Accès refusé.", http.StatusForbidden)
+		case errors.Is(err, ErrCannotKick):
+			http.Error(w, "Tu ne peux pas t'exclure toi-même.", http.StatusBadRequest)
+		case errors.Is(err, ErrPlayerNotFound):
+			http.Error(w, "Ce joueur n'est pas dans la salle.", http.StatusBadRequest)
+		default:
+			log.Printf("Exclusion salle %s (user %d) : %v", room.Code, targetID, err)
+			http.Error(w, "Erreur lors de l'exclusion.", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	BroadcastPlayerKicked(room.ID, targetID, pseudo, ban)
+	BroadcastRoomUpdated(room.ID)
+	if pseudo != "" {
+		BroadcastPlayerLeft(room.ID, pseudo)
+	}
+	CheckReadyState(room.ID)
+
+	http.Redirect(w, r, "/salle/"+room.Code, http.StatusSeeOther)
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// APIPetitBacPresetsHandler gère les modèles de catégories du Petit Bac :
+//
+//	GET    /api/presets/petitbac       modèles fournis + modèles du joueur
+//	POST   /api/presets/petitbac       création / import {"name": ..., "categories": [...]}
+//	GET    /api/presets/petitbac/{id}  export JSON d'un modèle
+//	DELETE /api/presets/petitbac/{id}  suppression d'un modèle du joueur
+func APIPetitBacPresetsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetSessionUserID(r)
+	if err != nil {
+		http.Error(w, "Non authentifié.", http.StatusUnauthorized)
+		return
+	}
+
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/presets/petitbac"), "/")
+	if rest == "" {
+		switch r.Method {
+		case http.MethodGet:
+			presets, err := ListPetitBacPresets(r.Context(), userID)
+			if err != nil {
+				log.Printf("Modèles Petit Bac : %v", err)
+				http.Error(w, "Erreur lors du chargement des modèles.", http.StatusInternalServerError)
+				return
+			}
+			if presets == nil {
+				presets = []PetitBacPreset{}
+			}
+			writeJSON(w, presets)
+		case http.MethodPost:
+			var body PetitBacPreset
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, "Requête invalide.", http.StatusBadRequest)
+				return
+			}
+			preset, err := CreatePetitBacPreset(r.Context(), userID, body)
+			if err != nil {
+				status, msg := petitBacPresetError(err)
+				http.Error(w, msg, status)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(preset)
+		default:
+			http.Error(w, "Méthode non autorisée.", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	id, err := strconv.Atoi(rest)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		preset, err := GetPetitBacPreset(r.Context(), userID, id)
+		if err != nil {
+			status, msg := petitBacPresetError(err)
+			http.Error(w, msg, status)
+			return
+		}
+		// format d'export : réimportable tel quel par POST
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"petitbac-%d.json\"", preset.ID))
+		writeJSON(w, PetitBacPreset{Name: preset.Name, Categories: preset.Categories})
+	case http.MethodDelete:
+		if err := DeletePetitBacPreset(r.Context(), userID, id); err != nil {
+			status, msg := petitBacPresetError(err)
+			http.Error(w, msg, status)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	default:
+		http.Error(w, "Méthode non autorisée.", http.StatusMethodNotAllowed)
+	}
+}
+
+// petitBacPresetError traduit une erreur de modèle en statut HTTP et message pour le joueur
+func petitBacPresetError(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrPresetNotFound):
+		return http.StatusNotFound, "Modèle introuvable."
+	case errors.Is(err, ErrInvalidPreset):
+		return http.StatusBadRequest, fmt.Sprintf("Modèle invalide (nom de 1 à %d caractères, 1 à %d catégories).", maxPresetNameLen, maxPetitBacCategories)
+	case errors.Is(err, ErrPresetNameTaken):
+		return http.StatusConflict, "Tu as déjà un modèle avec ce nom."
+	case errors.Is(err, ErrTooManyPresets):
+		return http.StatusConflict, fmt.Sprintf("Tu as déjà %d modèles, supprimes-en un.", maxUserPetitBacPresets)
+	case errors.Is(err, ErrRoomNotEditable):
+		return http.StatusConflict, "Impossible de changer les catégories pendant une partie."
+	}
+	log.Printf("Modèles Petit Bac : %v", err)
+	return http.StatusInternalServerError, "Erreur lors de l'enregistrement du modèle."
+}
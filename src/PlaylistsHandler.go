@@ -0,0 +1,325 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// PlaylistsPageData alimente playlists.html (liste) et playlist.html (détail)
+type PlaylistsPageData struct {
+	Playlists    []Playlist
+	Playlist     *Playlist
+	IsOwner      bool
+	LocalLibrary bool
+	Error        string
+}
+
+// PlaylistsHandler gère les pages /playlists (mes playlists) et /playlists/{id} (édition, partage)
+func PlaylistsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetSessionUserID(r)
+	if err != nil {
+		http.Redirect(w, r, "/connexion", http.StatusSeeOther)
+		return
+	}
+
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/playlists"), "/")
+	if rest == "" {
+		if r.Method == http.MethodPost {
+			p, err := CreatePlaylist(r.Context(), userID, r.FormValue("name"), nil)
+			if err != nil {
+				_, msg := playlistError(err)
+				lists, _ := ListPlaylists(r.Context(), userID)
+				renderTemplate(w, "playlists.html", PlaylistsPageData{Playlists: lists, Error: msg})
+				return
+			}
+			http.Redirect(w, r, fmt.Sprintf("/playlists/%d", p.ID), http.StatusSeeOther)
+			return
+		}
+		lists, err := ListPlaylists(r.Context(), userID)
+		if err != nil {
+			http.Error(w, "Erreur lors du chargement des playlists.", http.StatusInternalServerError)
+			return
+		}
+		renderTemplate(w, "playlists.html", PlaylistsPageData{Playlists: lists})
+		return
+	}
+
+	id, err := strconv.Atoi(rest)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		err := handlePlaylistForm(r, userID, id)
+		if err == nil {
+			if r.FormValue("section") == "delete" {
+				http.Redirect(w, r, "/playlists", http.StatusSeeOther)
+				return
+			}
+			http.Redirect(w, r, fmt.Sprintf("/playlists/%d", id), http.StatusSeeOther)
+			return
+		}
+		status, msg := playlistError(err)
+		if status == http.StatusInternalServerError || errors.Is(err, ErrPlaylistNotFound) {
+			http.Error(w, msg, status)
+			return
+		}
+		renderPlaylistPage(w, r, userID, id, msg)
+		return
+	}
+	renderPlaylistPage(w, r, userID, id, "")
+}
+
+func renderPlaylistPage(w http.ResponseWriter, r *http.Request, userID, id int, msg string) {
+	p, err := GetPlaylist(r.Context(), userID, id)
+	if err != nil {
+		status, msg := playlistError(err)
+		http.Error(w, msg, status)
+		return
+	}
+	renderTemplate(w, "playlist.html", PlaylistsPageData{
+		Playlist:     p,
+		IsOwner:      p.OwnerID == userID,
+		LocalLibrary: LocalLibraryAvailable(),
+		Error:        msg,
+	})
+}
+
+// handlePlaylistForm traite les formulaires de la page d'une playlist (identifiés par section)
+func handlePlaylistForm(r *http.Request, userID, id int) error {
+	switch r.FormValue("section") {
+	case "rename":
+		p, err := ownPlaylist(r.Context(), userID, id)
+		if err != nil {
+			return err
+		}
+		_, err = UpdatePlaylist(r.Context(), userID, id, r.FormValue("name"), p.Tracks)
+		return err
+	case "add_track":
+		t, err := playlistTrackFromForm(r)
+		if err != nil {
+			return err
+		}
+		return AddPlaylistTrack(r.Context(), userID, id, t)
+	case "delete_track":
+		trackID, _ := strconv.Atoi(r.FormValue("track_id"))
+		return RemovePlaylistTrack(r.Context(), userID, id, trackID)
+	case "share":
+		return SharePlaylist(r.Context(), userID, id, r.FormValue("pseudo"), true)
+	case "unshare":
+		return SharePlaylist(r.Context(), userID, id, r.FormValue("pseudo"), false)
+	case "delete":
+		return DeletePlaylist(r.Context(), userID, id)
+	}
+	return fmt.Errorf("%w: unknown form", ErrInvalidPlaylist)
+}
+
+// playlistTrackFromForm lit un titre ajouté à la main ; pour Deezer, titre et artiste sont
+// repris du titre Deezer quand ils sont laissés vides
+func playlistTrackFromForm(r *http.Request) (PlaylistTrack, error) {
+	t := PlaylistTrack{
+		Source:     r.FormValue("source"),
+		Ref:        r.FormValue("ref"),
+		Title:      r.FormValue("title"),
+		Artist:     r.FormValue("artist"),
+		Album:      r.FormValue("album"),
+		AltTitles:  splitAltAnswers(r.FormValue("alt_titles")),
+		AltArtists: splitAltAnswers(r.FormValue("alt_artists")),
+	}
+	if t.Source != PlaylistTrackDeezer {
+		return t, nil
+	}
+	trackID, err := parseDeezerTrackRef(t.Ref)
+	if err != nil {
+		return t, err
+	}
+	t.Ref = strconv.FormatInt(trackID, 10)
+	if strings.TrimSpace(t.Title) != "" && strings.TrimSpace(t.Artist) != "" {
+		return t, nil
+	}
+	info, err := DeezerTrackInfo(r.Context(), trackID)
+	if err != nil {
+		return t, err
+	}
+	if strings.TrimSpace(t.Title) == "" {
+		t.Title = info.Title
+	}
+	if strings.TrimSpace(t.Artist) == "" {
+		t.Artist = info.Artist
+	}
+	if strings.TrimSpace(t.Album) == "" {
+		t.Album = info.Album
+	}
+	return t, nil
+}
+
+// parseDeezerTrackRef accepte un identifiant de titre ou un lien https://www.deezer.com/fr/track/123
+func parseDeezerTrackRef(ref string) (int64, error) {
+	ref = strings.TrimSpace(ref)
+	if strings.Contains(ref, "deezer.com/") {
+		if !strings.Contains(ref, "://") {
+			ref = "https://" + ref
+		}
+		u, err := url.Parse(ref)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrInvalidPlaylist, err)
+		}
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(parts) < 2 || parts[len(parts)-2] != "track" {
+			return 0, fmt.Errorf("%w: not a deezer track link", ErrInvalidPlaylist)
+		}
+		ref = parts[len(parts)-1]
+	}
+	id, err := strconv.ParseInt(ref, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("%w: deezer track id %q", ErrInvalidPlaylist, ref)
+	}
+	return id, nil
+}
+
+// APIPlaylistsHandler expose les playlists en JSON :
+//
+//	GET    /api/playlists               mes playlists + celles partagées avec moi
+//	POST   /api/playlists               {"name": ..., "tracks": [...]}
+//	GET    /api/playlists/{id}          détail avec les titres
+//	PUT    /api/playlists/{id}          remplace nom et titres
+//	DELETE /api/playlists/{id}
+//	POST   /api/playlists/{id}/shares   {"pseudo": ...} partage ; DELETE pour le retirer
+func APIPlaylistsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := GetSessionUserID(r)
+	if err != nil {
+		http.Error(w, "Non authentifié.", http.StatusUnauthorized)
+		return
+	}
+
+	var body struct {
+		Name   string          `json:"name"`
+		Tracks []PlaylistTrack `json:"tracks"`
+		Pseudo string          `json:"pseudo"`
+	}
+	decode := func() bool {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Requête invalide.", http.StatusBadRequest)
+			return false
+		}
+		return true
+	}
+	fail := func(err error) {
+		status, msg := playlistError(err)
+		http.Error(w, msg, status)
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/playlists"), "/"), "/")
+	if parts[0] == "" {
+		switch r.Method {
+		case http.MethodGet:
+			lists, err := ListPlaylists(r.Context(), userID)
+			if err != nil {
+				fail(err)
+				return
+			}
+			if lists == nil {
+				lists = []Playlist{}
+			}
+			writeJSON(w, lists)
+		case http.MethodPost:
+			if !decode() {
+				return
+			}
+			p, err := CreatePlaylist(r.Context(), userID, body.Name, body.Tracks)
+			if err != nil {
+				fail(err)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(p)
+		default:
+			http.Error(w, "Méthode non autorisée.", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	id, err := strconv.Atoi(parts[0])
+	if err != nil || len(parts) > 2 || (len(parts) == 2 && parts[1] != "shares") {
+		http.NotFound(w, r)
+		return
+	}
+
+	if len(parts) == 2 {
+		if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+			http.Error(w, "Méthode non autorisée.", http.StatusMethodNotAllowed)
+			return
+		}
+		if !decode() {
+			return
+		}
+		if err := SharePlaylist(r.Context(), userID, id, body.Pseudo, r.Method == http.MethodPost); err != nil {
+			fail(err)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		p, err := GetPlaylist(r.Context(), userID, id)
+		if err != nil {
+			fail(err)
+			return
+		}
+		writeJSON(w, p)
+	case http.MethodPut:
+		if !decode() {
+			return
+		}
+		p, err := UpdatePlaylist(r.Context(), userID, id, body.Name, body.Tracks)
+		if err != nil {
+			fail(err)
+			return
+		}
+		writeJSON(w, p)
+	case http.MethodDelete:
+		if err := DeletePlaylist(r.Context(), userID, id); err != nil {
+			fail(err)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	default:
+		http.Error(w, "Méthode non autorisée.", http.StatusMethodNotAllowed)
+	}
+}
+
+// playlistError traduit une erreur de playlist en statut HTTP et message pour le joueur
+func playlistError(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrPlaylistNotFound):
+		return http.StatusNotFound, "Playlist introuvable."
+	case errors.Is(err, ErrPlaylistForbidden):
+		return http.StatusForbidden, "Seul le créateur de la playlist peut la modifier."
+	case errors.Is(err, ErrInvalidPlaylist):
+		return http.StatusBadRequest, fmt.Sprintf("Playlist invalide : nom de 1 à %d caractères, chaque titre avec une source (identifiant ou lien Deezer, fichier local) et un titre, %d réponses alternatives au plus.", maxPlaylistNameLen, maxAltAnswers)
+	case errors.Is(err, ErrPlaylistNameTaken):
+		return http.StatusConflict, "Tu as déjà une playlist avec ce nom."
+	case errors.Is(err, ErrTooManyPlaylists):
+		return http.StatusConflict, fmt.Sprintf("Tu as déjà %d playlists, supprimes-en une.", maxUserPlaylists)
+	case errors.Is(err, ErrPlaylistFull):
+		return http.StatusConflict, fmt.Sprintf("Une playlist contient %d titres au plus.", maxPlaylistTracks)
+	case errors.Is(err, ErrUserNotFound):
+		return http.StatusNotFound, "Joueur introuvable."
+	case errors.Is(err, ErrDeezerNotFound):
+		return http.StatusBadRequest, "Titre introuvable sur Deezer."
+	case errors.Is(err, ErrDeezerQuota), errors.Is(err, ErrDeezerUnavailable):
+		return http.StatusServiceUnavailable, deezerErrorMessage(err)
+	}
+	log.Printf("Playlists : %v", err)
+	return http.StatusInternalServerError, "Erreur lors de l'enregistrement de la playlist."
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"net/http"
+)
+
+// le PretSalleHandler permet à un joueur de se déclarer prêt (ou plus prêt) dans la salle d'attente
+
+func PretSalleHandler(w http.ResponseWriter, r *http.Request, code string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Méthode non autorisée.", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, err := GetSessionUserID(r)
+	if err != nil {
+		http.Redirect(w, r, "/connexion", http.StatusSeeOther)
+		return
+	}
+
+	room, err := GetRoomByCode(r.Context(), code)
+	if err != nil {
+		if errors.Is(err, ErrRoomNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, "Erreur room.", http.StatusInternalServerError)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Formulaire invalide.", http.StatusBadRequest)
+		return
+	}
+	ready := r.FormValue("ready") == "1"
+
+	if err := SetPlayerReady(r.Context(), room.ID, userID, ready); err != nil {
+		if errors.Is(err, ErrPlayerNotFound) {
+			http.Error(w, "Accès refusé.", http.StatusForbidden)
+			return
+		}
+		log.Printf("Ready salle %s (user %d) : %v", room.Code, userID, err)
+		http.Error(w, "Erreur lors de la mise à jour.", http.StatusInternalServerError)
+		return
+	}
+
+	CheckReadyState(room.ID)
+	BroadcastRoomUpdated(room.ID)
+	http.Redirect(w, r, "/salle/"+room.Code, http.StatusSeeOther)
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ProfilPageData contient les données de la page profil
+type ProfilPageData struct {
+	Profile   *PlayerProfile
+	IsSelf    bool
+	AvgAnswer string // temps moyen formaté ("4,2 s")
+	Validated string // taux formaté ("73 %")
+}
+
+// le ProfilHandler affiche le profil d'un joueur (/profil/{pseudo}) ; sans pseudo, celui du joueur connecté
+
+func ProfilHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Méthode non autorisée.", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, err := GetSessionUserID(r)
+	if err != nil {
+		http.Redirect(w, r, "/connexion", http.StatusSeeOther)
+		return
+	}
+
+	pseudo := strings.Trim(strings.TrimPrefix(r.URL.Path, "/profil/"), "/")
+	if pseudo == "" {
+		var self string
+		if err := Rekdb.QueryRowContext(r.Context(), SQLSelectUserPseudoByID, userID).Scan(&self); err != nil {
+			http.Error(w, "Utilisateur inconnu.", http.StatusNotFound)
+			return
+		}
+		http.Redirect(w, r, "/profil/"+url.PathEscape(self), http.StatusSeeOther)
+		return
+	}
+
+	profile, ok := loadProfile(w, r, pseudo)
+	if !ok {
+		return
+	}
+
+	data := ProfilPageData{
+		Profile:   profile,
+		IsSelf:    profile.UserID == userID,
+		AvgAnswer: "–",
+		Validated: "–",
+	}
+	if profile.BlindtestFound > 0 {
+		data.AvgAnswer = strings.Replace(fmt.Sprintf("%.1f s", float64(profile.AvgBlindtestAnswerMs)/1000), ".", ",", 1)
+	}
+	if profile.PetitBacAnswers > 0 {
+		data.Validated = fmt.Sprintf("%.0f %%", profile.PetitBacValidationRate*100)
+	}
+	renderTemplate(w, "profil.html", data)
+}
+
+// APIProfilHandler renvoie le profil en JSON (/api/profil/{pseudo})
+func APIProfilHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Méthode non autorisée.", http.StatusMethodNotAllowed)
+		return
+	}
+	pseudo := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/profil/"), "/")
+	if pseudo == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	profile, ok := loadProfile(w, r, pseudo)
+	if !ok {
+		return
+	}
+	writeJSON(w, profile)
+}
+
+func loadProfile(w http.ResponseWriter, r *http.Request, pseudo string) (*PlayerProfile, bool) {
+	profile, err := GetPlayerProfile(r.Context(), pseudo)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			http.Error(w, "Joueur introuvable.", http.StatusNotFound)
+			return nil, false
+		}
+		log.Printf("Profil %s : %v", pseudo, err)
+		http.Error(w, "Erreur lors du chargement du profil.", http.StatusInternalServerError)
+		return nil, false
+	}
+	return profile, true
+}
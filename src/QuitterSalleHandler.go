@@ -47,15 +47,29 @@ func QuitterSalleHandler(w http.ResponseWriter, r *http.Request, code string) {
 		pseudo = ""
 	}
 
-	if err := RemoveRoomPlayer(r.Context(), room.ID, userID); err != nil {
+	leave, err := RemoveRoomPlayer(r.Context(), room.ID, userID)
+	if err != nil {
 		http.Error(w, "Impossible de quitter la salle.", http.StatusInternalServerError)
 		return
 	}
 
+	// dernier joueur parti : la salle est archivée, on libère ses parties et son hub
+	if leave.Closed {
+		releaseRoom(room.ID)
+		http.Redirect(w, r, "/salle-initialisation", http.StatusSeeOther)
+		return
+	}
+
+	if leave.NewAdminID != 0 {
+		var newPseudo string
+		_ = Rekdb.QueryRowContext(r.Context(), SQLSelectUserPseudoByID, leave.NewAdminID).Scan(&newPseudo)
+		BroadcastAdminChanged(room.ID, leave.NewAdminID, newPseudo)
+	}
 	BroadcastRoomUpdated(room.ID)
 	if pseudo != "" {
 		BroadcastPlayerLeft(room.ID, pseudo)
 	}
+	CheckReadyState(room.ID)
 
 	http.Redirect(w, r, "/salle-initialisation", http.StatusSeeOther)
 }
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+const maxDeezerSearchResults = 15
+
+// APIDeezerHandler sert la page de config du Blindtest :
+//
+//	GET /api/deezer/genres            catalogue des genres
+//	GET /api/deezer/playlists?q=...   recherche de playlists
+func APIDeezerHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Méthode non autorisée.", http.StatusMethodNotAllowed)
+		return
+	}
+	switch strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/deezer/"), "/") {
+	case "genres":
+		genres, err := ListDeezerGenres(r.Context())
+		if err != nil {
+			log.Printf("Catalogue des genres Deezer : %v", err)
+			http.Error(w, deezerErrorMessage(err), deezerErrorStatus(err))
+			return
+		}
+		writeJSON(w, genres)
+
+	case "playlists":
+		q := strings.TrimSpace(r.URL.Query().Get("q"))
+		if q == "" {
+			writeJSON(w, []DeezerPlaylist{})
+			return
+		}
+		playlists, err := SearchDeezerPlaylists(r.Context(), q, maxDeezerSearchResults)
+		if err != nil {
+			log.Printf("Recherche Deezer %q : %v", q, err)
+			http.Error(w, deezerErrorMessage(err), deezerErrorStatus(err))
+			return
+		}
+		writeJSON(w, playlists)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// deezerErrorStatus : 429 quand Deezer limite les requêtes (le navigateur peut réessayer), 502 sinon
+func deezerErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrDeezerQuota):
+		return http.StatusTooManyRequests
+	case errors.Is(err, ErrDeezerNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusBadGateway
+}
@@ -50,6 +50,10 @@ func RejoindreSalleHandler(w http.ResponseWriter, r *http.Request) {
 		switch {
 		case errors.Is(err, ErrRoomCapacityReached):
 			http.Error(w, "La salle est complète.", http.StatusForbidden)
+		case errors.Is(err, ErrRoomClosed):
+			http.Error(w, "La salle est fermée.", http.StatusForbidden)
+		case errors.Is(err, ErrPlayerBanned):
+			http.Error(w, "Tu as été banni de cette salle.", http.StatusForbidden)
 		case errors.Is(err, ErrPlayerAlreadyInRoom):
 			http.Redirect(w, r, fmt.Sprintf("/salle/%s", room.Code), http.StatusSeeOther)
 		case errors.Is(err, ErrUserNotFound):
@@ -62,5 +66,6 @@ func RejoindreSalleHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	BroadcastRoomUpdated(room.ID)
+	CheckReadyState(room.ID)
 	http.Redirect(w, r, fmt.Sprintf("/salle/%s", room.Code), http.StatusSeeOther)
 }
\ No newline at end of file
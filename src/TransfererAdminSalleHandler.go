@@ -0,0 +1,64 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// le TransfererAdminSalleHandler permet à l'admin de passer la main à un autre joueur
+
+func TransfererAdminSalleHandler(w http.ResponseWriter, r *http.Request, code string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Méthode non autorisée.", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, err := GetSessionUserID(r)
+	if err != nil {
+		http.Redirect(w, r, "/connexion", http.StatusSeeOther)
+		return
+	}
+
+	room, err := GetRoomByCode(r.Context(), code)
+	if err != nil {
+		if errors.Is(err, ErrRoomNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, "Erreur room.", http.StatusInternalServerError)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Formulaire invalide.", http.StatusBadRequest)
+		return
+	}
+	targetID, err := strconv.Atoi(strings.TrimSpace(r.FormValue("user_id")))
+	if err != nil || targetID <= 0 {
+		http.Error(w, "Joueur invalide.", http.StatusBadRequest)
+		return
+	}
+
+	if err := TransferRoomAdmin(r.Context(), room.ID, userID, targetID); err != nil {
+		switch {
+		case errors.Is(err, ErrNotRoomAdmin):
+			http.Error(w, "Accès refusé.", http.StatusForbidden)
+		case errors.Is(err, ErrPlayerNotFound):
+			http.Error(w, "Ce joueur n'est pas dans la salle.", http.StatusBadRequest)
+		default:
+			log.Printf("Transfert admin salle %s : %v", room.Code, err)
+			http.Error(w, "Erreur lors du transfert.", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	var pseudo string
+	_ = Rekdb.QueryRowContext(r.Context(), SQLSelectUserPseudoByID, targetID).Scan(&pseudo)
+	BroadcastAdminChanged(room.ID, targetID, pseudo)
+	BroadcastRoomUpdated(room.ID)
+
+	http.Redirect(w, r, "/salle/"+room.Code, http.StatusSeeOther)
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+)
+
+// DeezerGenre est une entrée du catalogue des genres Deezer
+type DeezerGenre struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// DeezerPlaylist est un résultat de recherche de playlist
+type DeezerPlaylist struct {
+	ID       int64  `json:"id"`
+	Title    string `json:"title"`
+	NbTracks int    `json:"nb_tracks"`
+	User     string `json:"user"`
+}
+
+// previewWorkers limite les appels simultanés à Deezer au lancement d'une playlist de joueur
+const previewWorkers = 6
+
+// DeezerTrackInfo récupère un titre Deezer (ajout à une playlist, extrait au lancement)
+func DeezerTrackInfo(ctx context.Context, id int64) (BlindtestTrack, error) {
+	var t deezerTrack
+	if err := deezer.Get(ctx, fmt.Sprintf("/track/%d", id), &t); err != nil {
+		return BlindtestTrack{}, err
+	}
+	if t.ID == 0 {
+		return BlindtestTrack{}, ErrDeezerNotFound
+	}
+	return BlindtestTrack{TrackID: t.ID, PreviewURL: t.Preview, Title: t.Title, Artist: t.Artist.Name, Album: t.Album.Title}, nil
+}
+
+// FetchDeezerPreviews renvoie l'extrait de chaque titre (absent si introuvable ou sans extrait)
+func FetchDeezerPreviews(ctx context.Context, ids []int64) map[int64]string {
+	out := make(map[int64]string, len(ids))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, previewWorkers)
+	for _, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id int64) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			t, err := DeezerTrackInfo(ctx, id)
+			if err != nil || t.PreviewURL == "" {
+				return
+			}
+			mu.Lock()
+			out[id] = t.PreviewURL
+			mu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+	return out
+}
+
+// ListDeezerGenres renvoie le catalogue des genres (sans l'entrée « Tous », id 0)
+func ListDeezerGenres(ctx context.Context) ([]DeezerGenre, error) {
+	var resp struct {
+		Data []DeezerGenre `json:"data"`
+	}
+	if err := deezer.Get(ctx, "/genre", &resp); err != nil {
+		return nil, err
+	}
+	genres := make([]DeezerGenre, 0, len(resp.Data))
+	for _, g := range resp.Data {
+		if g.ID != 0 {
+			genres = append(genres, g)
+		}
+	}
+	return genres, nil
+}
+
+// SearchDeezerPlaylists cherche des playlists par mot-clé, les mieux notées d'abord
+func SearchDeezerPlaylists(ctx context.Context, query string, limit int) ([]DeezerPlaylist, error) {
+	var resp struct {
+		Data []struct {
+			ID       int64  `json:"id"`
+			Title    string `json:"title"`
+			NbTracks int    `json:"nb_tracks"`
+			User     struct {
+				Name string `json:"name"`
+			} `json:"user"`
+		} `json:"data"`
+	}
+	searchURL := fmt.Sprintf("/search/playlist?q=%s&order=RATING_DESC&limit=%d", url.QueryEscape(query), limit)
+	if err := deezer.Get(ctx, searchURL, &resp); err != nil {
+		return nil, err
+	}
+	out := make([]DeezerPlaylist, 0, len(resp.Data))
+	for _, p := range resp.Data {
+		out = append(out, DeezerPlaylist{ID: p.ID, Title: p.Title, NbTracks: p.NbTracks, User: p.User.Name})
+	}
+	return out, nil
+}
+
+// ResolveBlindtestSource vérifie que l'objet Deezer existe et renseigne son libellé
+func ResolveBlindtestSource(ctx context.Context, src BlindtestSource) (BlindtestSource, error) {
+	if err := src.Validate(); err != nil {
+		return src, err
+	}
+	switch src.Kind {
+	case SourceLocal, SourceSearch, SourceUser:
+		return src, nil
+	case SourceGenre:
+		genres, err := ListDeezerGenres(ctx)
+		if err != nil {
+			return src, err
+		}
+		for _, g := range genres {
+			if strconv.FormatInt(g.ID, 10) == src.ID {
+				src.Label = g.Name
+				return src, nil
+			}
+		}
+		return src, ErrDeezerNotFound
+	}
+
+	var obj struct {
+		Title string `json:"title"` // playlist, album
+		Name  string `json:"name"`  // artiste
+	}
+	if err := deezer.Get(ctx, fmt.Sprintf("/%s/%s", src.Kind, src.ID), &obj); err != nil {
+		return src, err
+	}
+	src.Label = obj.Title
+	if src.Label == "" {
+		src.Label = obj.Name
+	}
+	if src.Label == "" {
+		return src, ErrDeezerNotFound
+	}
+	return src, nil
+}
+
+// FetchDeezerSourceTracks récupère tous les titres jouables d'un genre, d'une playlist, d'un album ou d'un artiste
+func FetchDeezerSourceTracks(ctx context.Context, kind string, id int64) ([]BlindtestTrack, error) {
+	var tracksURL string
+	switch kind {
+	case SourceGenre:
+		tracksURL = fmt.Sprintf("/chart/%d/tracks?limit=100", id)
+	case SourcePlaylist:
+		tracksURL = fmt.Sprintf("/playlist/%d/tracks?limit=500", id)
+	case SourceArtist:
+		tracksURL = fmt.Sprintf("/artist/%d/top?limit=100", id)
+	case SourceAlbum:
+		// les titres d'un album n'ont pas le nom de l'album : on le lit sur l'album lui-même
+		var album struct {
+			Title  string `json:"title"`
+			Tracks struct {
+				Data []deezerTrack `json:"data"`
+			} `json:"tracks"`
+		}
+		if err := deezer.Get(ctx, fmt.Sprintf("/album/%d", id), &album); err != nil {
+			return nil, err
+		}
+		for i := range album.Tracks.Data {
+			album.Tracks.Data[i].Album.Title = album.Title
+		}
+		return playableTracks(album.Tracks.Data)
+	default:
+		return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalidBlindtestSource, kind)
+	}
+
+	var resp deezerResp
+	if err := deezer.Get(ctx, tracksURL, &resp); err != nil {
+		if errors.Is(err, ErrDeezerNotFound) {
+			return nil, fmt.Errorf("%s %d : %w", kind, id, err)
+		}
+		return nil, err
+	}
+	return playableTracks(resp.Data)
+}
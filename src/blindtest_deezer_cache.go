@@ -0,0 +1,281 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cache SQLite des listes de titres Deezer : une liste fraîche est servie telle quelle, une liste
+// périmée est servie pendant qu'on la rafraîchit en arrière-plan, et la dernière liste connue sert
+// de secours quand Deezer ne répond pas. Les extraits des playlists de joueurs sont gardés titre
+// par titre (clé deezer:track:<id>) tant que leur lien signé reste valide.
+const (
+	deezerCacheTTL         = 30 * time.Minute // au-delà, la liste est rafraîchie en arrière-plan
+	deezerCacheMaxStale    = 24 * time.Hour   // au-delà, elle est rechargée avant de lancer la partie
+	deezerRefreshTimeout   = 30 * time.Second
+	previewPrefetchTimeout = 2 * time.Minute  // 200 titres au rythme du quota Deezer
+	previewMargin          = 20 * time.Minute // un extrait doit rester valide le temps d'une partie
+)
+
+// deezerListSource est une source Deezer dont la liste complète est mise en cache
+type deezerListSource interface {
+	TrackSource
+	fetchList(ctx context.Context) ([]BlindtestTrack, error)
+}
+
+type deezerCacheEntry struct {
+	Tracks    []BlindtestTrack
+	FetchedAt time.Time
+}
+
+var (
+	deezerCacheHits   atomic.Int64
+	deezerCacheMisses atomic.Int64
+
+	// un seul rafraîchissement à la fois par source
+	deezerRefreshMu  sync.Mutex
+	deezerRefreshing = map[string]bool{}
+)
+
+func loadDeezerCache(ctx context.Context, key string) (*deezerCacheEntry, error) {
+	var raw string
+	var fetchedAt int64
+	err := Rekdb.QueryRowContext(ctx, SQLSelectDeezerTrackCache, key).Scan(&raw, &fetchedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	entry := &deezerCacheEntry{FetchedAt: time.Unix(fetchedAt, 0)}
+	if err := json.Unmarshal([]byte(raw), &entry.Tracks); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func storeDeezerCache(ctx context.Context, key string, tracks []BlindtestTrack) error {
+	raw, err := json.Marshal(tracks)
+	if err != nil {
+		return err
+	}
+	_, err = Rekdb.ExecContext(ctx, SQLUpsertDeezerTrackCache, key, string(raw), len(tracks), time.Now().Unix())
+	return err
+}
+
+// previewExpiry lit l'expiration d'un extrait signé (…?hdnea=exp=1700000000~acl=…)
+func previewExpiry(preview string) (time.Time, bool) {
+	u, err := url.Parse(preview)
+	if err != nil {
+		return time.Time{}, false
+	}
+	for _, part := range strings.Split(u.Query().Get("hdnea"), "~") {
+		if v, ok := strings.CutPrefix(part, "exp="); ok {
+			if exp, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return time.Unix(exp, 0), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// usableTracks garde les titres dont l'extrait sera encore valide pendant la partie
+func usableTracks(tracks []BlindtestTrack, now time.Time) []BlindtestTrack {
+	out := make([]BlindtestTrack, 0, len(tracks))
+	for _, t := range tracks {
+		if exp, ok := previewExpiry(t.PreviewURL); ok && exp.Before(now.Add(previewMargin)) {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// cachedDeezerTracks sert les titres d'une partie depuis le cache quand c'est possible
+func cachedDeezerTracks(ctx context.Context, src deezerListSource) ([]BlindtestTrack, error) {
+	if Rekdb == nil {
+		tracks, err := src.fetchList(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return pickGameTracks(tracks), nil
+	}
+
+	key := src.Name()
+	now := time.Now()
+	entry, err := loadDeezerCache(ctx, key)
+	if err != nil {
+		log.Printf("Cache Deezer %s : lecture impossible : %v", key, err)
+	}
+	var usable []BlindtestTrack
+	if entry != nil {
+		usable = usableTracks(entry.Tracks, now)
+		age := now.Sub(entry.FetchedAt)
+		// la moitié des extraits au moins doit être encore valide, sinon on recharge
+		if age < deezerCacheMaxStale && len(usable) > 0 && len(usable)*2 >= len(entry.Tracks) {
+			hits := deezerCacheHits.Add(1)
+			if age < deezerCacheTTL {
+				log.Printf("Cache Deezer %s : hit (%s, %d titres) [hits %d / misses %d]", key, age.Round(time.Second), len(usable), hits, deezerCacheMisses.Load())
+			} else {
+				log.Printf("Cache Deezer %s : hit périmé (%s), rafraîchissement en arrière-plan [hits %d / misses %d]", key, age.Round(time.Second), hits, deezerCacheMisses.Load())
+				go refreshDeezerCache(src)
+			}
+			return pickGameTracks(usable), nil
+		}
+	}
+
+	misses := deezerCacheMisses.Add(1)
+	log.Printf("Cache Deezer %s : miss [hits %d / misses %d]", key, deezerCacheHits.Load(), misses)
+	tracks, err := src.fetchList(ctx)
+	if err != nil {
+		if len(usable) > 0 {
+			log.Printf("Cache Deezer %s : %v, dernière liste connue (%s)", key, err, now.Sub(entry.FetchedAt).Round(time.Second))
+			return pickGameTracks(usable), nil
+		}
+		return nil, err
+	}
+	if err := storeDeezerCache(ctx, key, tracks); err != nil {
+		log.Printf("Cache Deezer %s : écriture impossible : %v", key, err)
+	}
+	return pickGameTracks(tracks), nil
+}
+
+// refreshDeezerCache recharge une liste depuis Deezer ; en cas d'échec l'ancienne liste est gardée
+func refreshDeezerCache(src deezerListSource) {
+	key := src.Name()
+	deezerRefreshMu.Lock()
+	if deezerRefreshing[key] {
+		deezerRefreshMu.Unlock()
+		return
+	}
+	deezerRefreshing[key] = true
+	deezerRefreshMu.Unlock()
+	defer func() {
+		deezerRefreshMu.Lock()
+		delete(deezerRefreshing, key)
+		deezerRefreshMu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), deezerRefreshTimeout)
+	defer cancel()
+	tracks, err := src.fetchList(ctx)
+	if err != nil {
+		log.Printf("Cache Deezer %s : rafraîchissement impossible : %v", key, err)
+		return
+	}
+	if err := storeDeezerCache(ctx, key, tracks); err != nil {
+		log.Printf("Cache Deezer %s : écriture impossible : %v", key, err)
+		return
+	}
+	log.Printf("Cache Deezer %s : rafraîchi (%d titres)", key, len(tracks))
+}
+
+func deezerTrackKey(id int64) string {
+	return fmt.Sprintf("deezer:track:%d", id)
+}
+
+// cachedDeezerPreviews renvoie l'extrait de chaque titre (absent si introuvable ou sans extrait) ;
+// seuls les titres sans extrait valide en cache sont demandés à Deezer
+func cachedDeezerPreviews(ctx context.Context, ids []int64) map[int64]string {
+	if Rekdb == nil {
+		return FetchDeezerPreviews(ctx, ids)
+	}
+	out := make(map[int64]string, len(ids))
+	var missing []int64
+	now := time.Now()
+	for _, id := range ids {
+		entry, err := loadDeezerCache(ctx, deezerTrackKey(id))
+		if err == nil && entry != nil && now.Sub(entry.FetchedAt) < deezerCacheMaxStale {
+			if usable := usableTracks(entry.Tracks, now); len(usable) == 1 {
+				out[id] = usable[0].PreviewURL
+				continue
+			}
+		}
+		missing = append(missing, id)
+	}
+	if len(ids) > 0 {
+		hits := deezerCacheHits.Add(int64(len(out)))
+		misses := deezerCacheMisses.Add(int64(len(missing)))
+		log.Printf("Cache Deezer extraits : %d en cache, %d à demander [hits %d / misses %d]", len(out), len(missing), hits, misses)
+	}
+
+	for id, preview := range FetchDeezerPreviews(ctx, missing) {
+		out[id] = preview
+		if err := storeDeezerCache(ctx, deezerTrackKey(id), []BlindtestTrack{{TrackID: id, PreviewURL: preview}}); err != nil {
+			log.Printf("Cache Deezer %s : écriture impossible : %v", deezerTrackKey(id), err)
+		}
+	}
+	return out
+}
+
+// prefetchPlaylistPreviews met en cache les extraits de tous les titres Deezer d'une playlist de joueur
+func prefetchPlaylistPreviews(src UserPlaylistSource) {
+	key := src.Name()
+	deezerRefreshMu.Lock()
+	if deezerRefreshing[key] {
+		deezerRefreshMu.Unlock()
+		return
+	}
+	deezerRefreshing[key] = true
+	deezerRefreshMu.Unlock()
+	defer func() {
+		deezerRefreshMu.Lock()
+		delete(deezerRefreshing, key)
+		deezerRefreshMu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), previewPrefetchTimeout)
+	defer cancel()
+	tracks, err := listPlaylistTracks(ctx, src.PlaylistID)
+	if err != nil {
+		log.Printf("Cache Deezer %s : lecture de la playlist impossible : %v", key, err)
+		return
+	}
+	var ids []int64
+	for _, t := range tracks {
+		if t.Source == PlaylistTrackDeezer {
+			if id, err := strconv.ParseInt(t.Ref, 10, 64); err == nil {
+				ids = append(ids, id)
+			}
+		}
+	}
+	previews := cachedDeezerPreviews(ctx, ids)
+	log.Printf("Cache Deezer %s : %d extraits sur %d titres Deezer", key, len(previews), len(ids))
+}
+
+// PrefetchBlindtestSource réchauffe le cache dès que l'admin choisit sa source, pour que le
+// lancement de la partie n'attende pas Deezer : la liste (avec ses extraits) pour une source
+// Deezer, les extraits de chaque titre pour une playlist de joueur
+func PrefetchBlindtestSource(src BlindtestSource) {
+	source, err := TrackSourceFor(src, 0)
+	if err != nil || Rekdb == nil {
+		return
+	}
+	if ups, ok := source.(UserPlaylistSource); ok {
+		go prefetchPlaylistPreviews(ups)
+		return
+	}
+	ls, ok := source.(deezerListSource)
+	if !ok {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), deezerRefreshTimeout)
+		defer cancel()
+		if entry, err := loadDeezerCache(ctx, ls.Name()); err == nil && entry != nil &&
+			time.Since(entry.FetchedAt) < deezerCacheTTL && len(usableTracks(entry.Tracks, time.Now()))*2 >= len(entry.Tracks) {
+			return
+		}
+		refreshDeezerCache(ls)
+	}()
+}
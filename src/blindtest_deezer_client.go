@@ -0,0 +1,229 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Quota de l'API publique Deezer : 50 requêtes par tranche de 5 secondes
+const (
+	deezerQuotaRequests = 50
+	deezerQuotaWindow   = 5 * time.Second
+
+	deezerDefaultAPI  = "https://api.deezer.com"
+	deezerTimeout     = 10 * time.Second
+	deezerMaxRetries  = 3
+	deezerBackoffBase = 500 * time.Millisecond
+	deezerBackoffMax  = 5 * time.Second
+)
+
+// Codes d'erreur renvoyés dans {"error": {"code": …}}
+const (
+	deezerCodeQuota       = 4
+	deezerCodeServiceBusy = 700
+	deezerCodeNotFound    = 800
+)
+
+var (
+	ErrDeezerNotFound    = errors.New("introuvable sur Deezer")
+	ErrDeezerQuota       = errors.New("quota de requêtes Deezer dépassé")
+	ErrDeezerUnavailable = errors.New("Deezer injoignable")
+)
+
+// DeezerError est une erreur renvoyée par Deezer (objet error du JSON ou statut HTTP)
+type DeezerError struct {
+	Status  int // statut HTTP
+	Code    int // code Deezer, 0 si absent
+	Type    string
+	Message string
+}
+
+func (e *DeezerError) Error() string {
+	if e.Code != 0 {
+		return fmt.Sprintf("deezer: %s (%s %d)", e.Message, e.Type, e.Code)
+	}
+	return fmt.Sprintf("deezer: HTTP %d", e.Status)
+}
+
+// Is rattache l'erreur à ErrDeezerNotFound, ErrDeezerQuota ou ErrDeezerUnavailable
+func (e *DeezerError) Is(target error) bool {
+	switch target {
+	case ErrDeezerNotFound:
+		return e.Code == deezerCodeNotFound || (e.Code == 0 && e.Status == http.StatusNotFound)
+	case ErrDeezerQuota:
+		return e.Code == deezerCodeQuota || e.Status == http.StatusTooManyRequests
+	case ErrDeezerUnavailable:
+		return e.Code == deezerCodeServiceBusy || e.Status >= 500
+	}
+	return false
+}
+
+// DeezerClient appelle l'API Deezer avec un transport partagé, en respectant le quota
+// et en réessayant les erreurs passagères
+type DeezerClient struct {
+	BaseURL    string
+	HTTP       *http.Client
+	MaxRetries int
+	limiter    *rateLimiter
+}
+
+// NewDeezerClient crée un client ; baseURL vide = API publique (un httptest.Server pour les tests)
+func NewDeezerClient(baseURL string) *DeezerClient {
+	if baseURL == "" {
+		baseURL = deezerDefaultAPI
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = previewWorkers
+	return &DeezerClient{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTP:       &http.Client{Timeout: deezerTimeout, Transport: transport},
+		MaxRetries: deezerMaxRetries,
+		limiter:    newRateLimiter(deezerQuotaRequests, deezerQuotaWindow),
+	}
+}
+
+// deezer est le client utilisé par le serveur
+var deezer = NewDeezerClient("")
+
+// SetDeezerBaseURL change l'adresse de l'API (option -deezer-api, proxy ou bouchon de test)
+func SetDeezerBaseURL(baseURL string) {
+	deezer = NewDeezerClient(baseURL)
+}
+
+// Get lit path (ex. /track/3135556) et décode la réponse JSON dans out
+func (c *DeezerClient) Get(ctx context.Context, path string, out any) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = c.limiter.wait(ctx); err != nil {
+			return err
+		}
+		var body []byte
+		body, err = c.do(ctx, path)
+		if err == nil {
+			if err = json.Unmarshal(body, out); err != nil {
+				return fmt.Errorf("deezer: réponse illisible pour %s: %w", path, err)
+			}
+			return nil
+		}
+
+		// quota, service occupé et erreurs serveur passent après une pause
+		retry := errors.Is(err, ErrDeezerQuota) || errors.Is(err, ErrDeezerUnavailable)
+		if !retry || attempt >= c.MaxRetries || ctx.Err() != nil {
+			return err
+		}
+		select {
+		case <-time.After(deezerBackoff(attempt)):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// do envoie une requête et renvoie le corps, ou l'erreur Deezer qu'il contient
+func (c *DeezerClient) do(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) || errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: %v", ErrDeezerUnavailable, err)
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDeezerUnavailable, err)
+	}
+
+	// Deezer répond souvent 200 avec {"error": {...}} (objet absent, quota dépassé)
+	var apiErr struct {
+		Error *struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+			Code    int    `json:"code"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Error != nil {
+		return nil, &DeezerError{Status: resp.StatusCode, Code: apiErr.Error.Code, Type: apiErr.Error.Type, Message: apiErr.Error.Message}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &DeezerError{Status: resp.StatusCode}
+	}
+	return body, nil
+}
+
+// deezerBackoff : 0,5 s, 1 s, 2 s… plafonné, avec un peu d'aléa pour ne pas repartir tous ensemble
+func deezerBackoff(attempt int) time.Duration {
+	d := deezerBackoffBase << attempt
+	if d > deezerBackoffMax {
+		d = deezerBackoffMax
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// rateLimiter laisse passer au plus limit requêtes par fenêtre glissante
+type rateLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	sent   []time.Time
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{limit: limit, window: window}
+}
+
+func (l *rateLimiter) wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		i := 0
+		for i < len(l.sent) && now.Sub(l.sent[i]) >= l.window {
+			i++
+		}
+		l.sent = l.sent[i:]
+		if len(l.sent) < l.limit {
+			l.sent = append(l.sent, now)
+			l.mu.Unlock()
+			return nil
+		}
+		delay := l.window - now.Sub(l.sent[0])
+		l.mu.Unlock()
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// deezerErrorMessage explique à l'admin pourquoi Deezer n'a pas répondu
+func deezerErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrDeezerNotFound):
+		return "Introuvable sur Deezer : vérifie l'identifiant ou le lien."
+	case errors.Is(err, ErrDeezerQuota):
+		return "Deezer limite le nombre de requêtes, réessaie dans quelques secondes."
+	case errors.Is(err, context.DeadlineExceeded):
+		return "Deezer met trop de temps à répondre, réessaie dans un instant."
+	}
+	return "Deezer injoignable, réessaie dans un instant."
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// deezerStub répond successivement les réponses données (la dernière est répétée)
+func deezerStub(t *testing.T, responses ...func(w http.ResponseWriter)) (*DeezerClient, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := int(calls.Add(1)) - 1
+		responses[min(i, len(responses)-1)](w)
+	}))
+	t.Cleanup(srv.Close)
+	return NewDeezerClient(srv.URL), &calls
+}
+
+func reply(status int, body string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+const deezerTrackJSON = `{"id": 3135556, "title": "Harder, Better, Faster, Stronger"}`
+
+type deezerTrackStub struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+func TestDeezerClientRetriesQuotaInOKResponse(t *testing.T) {
+	c, calls := deezerStub(t,
+		reply(http.StatusOK, `{"error": {"type": "Exception", "message": "Quota limit exceeded", "code": 4}}`),
+		reply(http.StatusOK, deezerTrackJSON),
+	)
+	var track deezerTrackStub
+	if err := c.Get(context.Background(), "/track/3135556", &track); err != nil {
+		t.Fatalf("Get : %v", err)
+	}
+	if track.ID != 3135556 {
+		t.Errorf("titre %+v, attendu l'id 3135556", track)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("%d appels, attendu 2 (quota puis nouvel essai)", n)
+	}
+}
+
+func TestDeezerClientQuotaExhaustedRetries(t *testing.T) {
+	c, calls := deezerStub(t, reply(http.StatusOK, `{"error": {"type": "Exception", "message": "Quota limit exceeded", "code": 4}}`))
+	c.MaxRetries = 1
+	err := c.Get(context.Background(), "/track/1", &deezerTrackStub{})
+	if !errors.Is(err, ErrDeezerQuota) {
+		t.Fatalf("erreur %v, attendu ErrDeezerQuota", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("%d appels, attendu 2", n)
+	}
+}
+
+func TestDeezerClientBacksOffOnServerError(t *testing.T) {
+	c, calls := deezerStub(t,
+		reply(http.StatusBadGateway, `bad gateway`),
+		reply(http.StatusServiceUnavailable, ``),
+		reply(http.StatusOK, deezerTrackJSON),
+	)
+	start := time.Now()
+	if err := c.Get(context.Background(), "/track/3135556", &deezerTrackStub{}); err != nil {
+		t.Fatalf("Get : %v", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("%d appels, attendu 3", n)
+	}
+	// deux pauses : au moins la moitié de 0,5 s puis de 1 s
+	if elapsed := time.Since(start); elapsed < (deezerBackoffBase+2*deezerBackoffBase)/2 {
+		t.Errorf("nouveaux essais après %s, attendu une pause entre chaque", elapsed)
+	}
+}
+
+func TestDeezerClientServerErrorGivesUp(t *testing.T) {
+	c, calls := deezerStub(t, reply(http.StatusInternalServerError, ``))
+	c.MaxRetries = 0
+	err := c.Get(context.Background(), "/track/1", &deezerTrackStub{})
+	if !errors.Is(err, ErrDeezerUnavailable) {
+		t.Fatalf("erreur %v, attendu ErrDeezerUnavailable", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("%d appels, attendu 1", n)
+	}
+}
+
+func TestDeezerClientNotFound(t *testing.T) {
+	c, calls := deezerStub(t, reply(http.StatusOK, `{"error": {"type": "DataException", "message": "no data", "code": 800}}`))
+	err := c.Get(context.Background(), "/track/1", &deezerTrackStub{})
+	if !errors.Is(err, ErrDeezerNotFound) {
+		t.Fatalf("erreur %v, attendu ErrDeezerNotFound", err)
+	}
+	var de *DeezerError
+	if !errors.As(err, &de) || de.Code != deezerCodeNotFound {
+		t.Errorf("erreur %#v, attendu un DeezerError code 800", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("%d appels, attendu 1 (introuvable ne se réessaie pas)", n)
+	}
+}
+
+func TestRateLimiterSlidingWindow(t *testing.T) {
+	const window = 200 * time.Millisecond
+	l := newRateLimiter(3, window)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > window/2 {
+		t.Fatalf("les 3 premières requêtes ont attendu %s", elapsed)
+	}
+	if err := l.wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < window {
+		t.Errorf("4e requête après %s, attendu au moins %s", elapsed, window)
+	}
+
+	// fenêtre pleine : l'attente s'arrête avec le contexte
+	l = newRateLimiter(1, time.Hour)
+	_ = l.wait(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := l.wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("erreur %v, attendu context.DeadlineExceeded", err)
+	}
+}
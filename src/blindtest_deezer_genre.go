@@ -2,14 +2,11 @@ package server
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand"
-	"net/http"
 	"net/url"
 	"strings"
-	"time"
 )
 
 // BlindtestTrack représente une piste jouable
@@ -18,30 +15,28 @@ type BlindtestTrack struct {
 	PreviewURL string
 	Title      string
 	Artist     string
+	Album      string
+	Year       int
+	AltTitles  []string // réponses acceptées en plus (playlists des joueurs)
+	AltArtists []string
 }
 
-// Structure simplifiée pour lire la réponse JSON de Deezer
-type deezerResp struct {
-	Data []struct {
-		ID      int64  `json:"id"`
-		Title   string `json:"title"`
-		Preview string `json:"preview"`
-		Artist  struct {
-			Name string `json:"name"`
-		} `json:"artist"`
-	} `json:"data"`
+// deezerTrack est un titre tel que renvoyé par les listes Deezer (playlist, classement, top artiste)
+type deezerTrack struct {
+	ID      int64  `json:"id"`
+	Title   string `json:"title"`
+	Preview string `json:"preview"`
+	Artist  struct {
+		Name string `json:"name"`
+	} `json:"artist"`
+	Album struct {
+		Title string `json:"title"`
+	} `json:"album"`
 }
 
-// Fonction utilitaire pour télécharger le JSON
-func deezerGet(ctx context.Context, url string, out any) error {
-	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	return json.NewDecoder(resp.Body).Decode(out)
+// Structure simplifiée pour lire la réponse JSON de Deezer
+type deezerResp struct {
+	Data []deezerTrack `json:"data"`
 }
 
 // Choix intelligent de la playlist (Mélange FR/Inter inclus dans ces playlists)
@@ -58,7 +53,8 @@ func getSimpleQuery(genre string) string {
 	}
 }
 
-// FetchDeezerGenreTracks récupère 500 titres, mélange, et renvoie les 100 meilleurs
+// FetchDeezerGenreTracks récupère les 500 titres de la meilleure playlist du genre ;
+// le mélange et la coupe à 100 se font au lancement (pickGameTracks)
 
 func FetchDeezerGenreTracks(ctx context.Context, playlistType string) ([]BlindtestTrack, error) {
 	// la proicédure est la suivante : commence tout dabord
@@ -68,10 +64,13 @@ func FetchDeezerGenreTracks(ctx context.Context, playlistType string) ([]Blindte
 
 	// On cherche la playlist la mieux notée (RATING_DESC) genre ce qui est dejà confirmé par les utilisateurs
 
-	searchURL := fmt.Sprintf("https://api.deezer.com/search/playlist?q=%s&order=RATING_DESC&limit=1", url.QueryEscape(query))
+	searchURL := fmt.Sprintf("/search/playlist?q=%s&order=RATING_DESC&limit=1", url.QueryEscape(query))
 
 	var searchResp deezerResp
-	if err := deezerGet(ctx, searchURL, &searchResp); err != nil || len(searchResp.Data) == 0 {
+	if err := deezer.Get(ctx, searchURL, &searchResp); err != nil {
+		return nil, err
+	}
+	if len(searchResp.Data) == 0 {
 		return nil, errors.New("aucune playlist trouvée")
 	}
 
@@ -79,18 +78,22 @@ func FetchDeezerGenreTracks(ctx context.Context, playlistType string) ([]Blindte
 
 	// 2. LE SECRET DE LA VARIÉTÉ : On récupère 500 chansons (le max) pour eviter que un joeurs capte les musique a force d'y jouer.
 
-	tracksURL := fmt.Sprintf("https://api.deezer.com/playlist/%d/tracks?limit=500", bestPlaylistID)
+	tracksURL := fmt.Sprintf("/playlist/%d/tracks?limit=500", bestPlaylistID)
 	var tracksResp deezerResp
-	if err := deezerGet(ctx, tracksURL, &tracksResp); err != nil {
+	if err := deezer.Get(ctx, tracksURL, &tracksResp); err != nil {
 		return nil, err
 	}
+	return playableTracks(tracksResp.Data)
+}
 
+// playableTracks filtre une liste de titres Deezer (liste complète, mise en cache telle quelle)
+func playableTracks(data []deezerTrack) ([]BlindtestTrack, error) {
 	// 3. Filtrage : On garde uniquement celles avec un extrait audio pour pouvoir jouer en fonction du temps imparti configurer par l'administrateur de la salle
 
 	var cleanTracks []BlindtestTrack
 	seen := make(map[int64]bool)
 
-	for _, t := range tracksResp.Data {
+	for _, t := range data {
 		if t.Preview == "" || seen[t.ID] {
 			continue
 		}
@@ -101,6 +104,7 @@ func FetchDeezerGenreTracks(ctx context.Context, playlistType string) ([]Blindte
 			PreviewURL: t.Preview,
 			Title:      t.Title,
 			Artist:     t.Artist.Name,
+			Album:      t.Album.Title,
 		})
 	}
 
@@ -108,17 +112,20 @@ func FetchDeezerGenreTracks(ctx context.Context, playlistType string) ([]Blindte
 		return nil, errors.New("aucune chanson jouable trouvée")
 	}
 
-	// 4. LE SHUFFLE : On mélange tout le paquet genre remier pour rendre la sélection aléatoire
+	return cleanTracks, nil
+}
 
-	rand.Seed(time.Now().UnixNano())
-	rand.Shuffle(len(cleanTracks), func(i, j int) {
-		cleanTracks[i], cleanTracks[j] = cleanTracks[j], cleanTracks[i]
+// pickGameTracks tire les titres d'une partie dans une liste complète, sans la modifier
+func pickGameTracks(tracks []BlindtestTrack) []BlindtestTrack {
+	// 4. LE SHUFFLE : On mélange tout le paquet genre remier pour rendre la sélection aléatoire
+	picked := append([]BlindtestTrack(nil), tracks...)
+	rand.Shuffle(len(picked), func(i, j int) {
+		picked[i], picked[j] = picked[j], picked[i]
 	})
 
 	// 5. LA COUPE : On ne garde que les 100 premières du mélange pour la partie pour éviter les répétitions
-	if len(cleanTracks) > 100 {
-		cleanTracks = cleanTracks[:100]
+	if len(picked) > maxGameTracks {
+		picked = picked[:maxGameTracks]
 	}
-
-	return cleanTracks, nil
+	return picked
 }
@@ -0,0 +1,297 @@
+package server
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrLocalLibraryDisabled = errors.New("bibliothèque locale non configurée")
+	ErrLocalLibraryEmpty    = errors.New("aucune chanson trouvée dans la bibliothèque locale")
+)
+
+const (
+	localManifestJSON = "manifest.json"
+	localManifestCSV  = "manifest.csv"
+	mediaRoutePrefix  = "/media/"
+)
+
+var localAudioExtensions = map[string]string{
+	".mp3":  "audio/mpeg",
+	".ogg":  "audio/ogg",
+	".oga":  "audio/ogg",
+	".opus": "audio/ogg",
+	".m4a":  "audio/mp4",
+	".aac":  "audio/aac",
+	".wav":  "audio/wav",
+	".flac": "audio/flac",
+}
+
+// LocalManifestEntry décrit un fichier audio de la bibliothèque (manifest.json ou manifest.csv)
+type LocalManifestEntry struct {
+	File   string `json:"file"`
+	Title  string `json:"title"`
+	Artist string `json:"artist"`
+	Album  string `json:"album"`
+	Year   int    `json:"year"`
+}
+
+// LocalLibrary est une source de titres hors ligne : un dossier de fichiers audio + un manifeste
+type LocalLibrary struct {
+	Dir string
+}
+
+var (
+	localLibraryMu sync.RWMutex
+	localLibrary   *LocalLibrary
+)
+
+// SetLocalLibraryDir active la bibliothèque locale (dossier vide = désactivée)
+
+func SetLocalLibraryDir(dir string) error {
+	dir = strings.TrimSpace(dir)
+	localLibraryMu.Lock()
+	defer localLibraryMu.Unlock()
+	if dir == "" {
+		localLibrary = nil
+		return nil
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s n'est pas un dossier", dir)
+	}
+	localLibrary = &LocalLibrary{Dir: dir}
+	return nil
+}
+
+func GetLocalLibrary() *LocalLibrary {
+	localLibraryMu.RLock()
+	defer localLibraryMu.RUnlock()
+	return localLibrary
+}
+
+func LocalLibraryAvailable() bool {
+	return GetLocalLibrary() != nil
+}
+
+func (l *LocalLibrary) Name() string {
+	return "local"
+}
+
+// Tracks scanne le dossier, applique le manifeste, mélange et garde 100 titres comme pour Deezer
+
+func (l *LocalLibrary) Tracks(ctx context.Context) ([]BlindtestTrack, error) {
+	manifest, err := l.loadManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	var tracks []BlindtestTrack
+	err = filepath.WalkDir(l.Dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if _, ok := localAudioExtensions[strings.ToLower(filepath.Ext(p))]; !ok {
+			return nil
+		}
+		rel, err := filepath.Rel(l.Dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		entry, ok := manifest[rel]
+		if !ok {
+			entry = entryFromFileName(rel)
+		}
+		if strings.TrimSpace(entry.Title) == "" {
+			return nil
+		}
+
+		tracks = append(tracks, BlindtestTrack{
+			TrackID:    localTrackID(rel),
+			PreviewURL: mediaRoutePrefix + escapeMediaPath(rel),
+			Title:      entry.Title,
+			Artist:     entry.Artist,
+			Album:      entry.Album,
+			Year:       entry.Year,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(tracks) == 0 {
+		return nil, ErrLocalLibraryEmpty
+	}
+
+	rand.Shuffle(len(tracks), func(i, j int) {
+		tracks[i], tracks[j] = tracks[j], tracks[i]
+	})
+	if len(tracks) > 100 {
+		tracks = tracks[:100]
+	}
+	return tracks, nil
+}
+
+// loadManifest lit manifest.json en priorité, sinon manifest.csv (entête : file,title,artist,album,year)
+
+func (l *LocalLibrary) loadManifest() (map[string]LocalManifestEntry, error) {
+	out := map[string]LocalManifestEntry{}
+
+	if f, err := os.Open(filepath.Join(l.Dir, localManifestJSON)); err == nil {
+		defer f.Close()
+		var entries []LocalManifestEntry
+		if err := json.NewDecoder(f).Decode(&entries); err != nil {
+			return nil, fmt.Errorf("%s invalide : %w", localManifestJSON, err)
+		}
+		for _, e := range entries {
+			out[cleanManifestPath(e.File)] = e
+		}
+		return out, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	f, err := os.Open(filepath.Join(l.Dir, localManifestCSV))
+	if errors.Is(err, fs.ErrNotExist) {
+		return out, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return out, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s invalide : %w", localManifestCSV, err)
+	}
+	cols := map[string]int{}
+	for i, h := range header {
+		cols[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	if _, ok := cols["file"]; !ok {
+		return nil, fmt.Errorf("%s : colonne \"file\" manquante", localManifestCSV)
+	}
+	get := func(rec []string, col string) string {
+		i, ok := cols[col]
+		if !ok || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+
+	for {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s invalide : %w", localManifestCSV, err)
+		}
+		year, _ := strconv.Atoi(get(rec, "year"))
+		e := LocalManifestEntry{
+			File:   get(rec, "file"),
+			Title:  get(rec, "title"),
+			Artist: get(rec, "artist"),
+			Album:  get(rec, "album"),
+			Year:   year,
+		}
+		out[cleanManifestPath(e.File)] = e
+	}
+	return out, nil
+}
+
+func cleanManifestPath(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(strings.TrimSpace(p))), "/")
+}
+
+// entryFromFileName devine "Artiste - Titre" quand le fichier n'est pas dans le manifeste
+
+func entryFromFileName(rel string) LocalManifestEntry {
+	base := strings.TrimSuffix(path.Base(rel), path.Ext(rel))
+	if artist, title, ok := strings.Cut(base, " - "); ok {
+		return LocalManifestEntry{File: rel, Title: strings.TrimSpace(title), Artist: strings.TrimSpace(artist)}
+	}
+	return LocalManifestEntry{File: rel, Title: strings.TrimSpace(base)}
+}
+
+func localTrackID(rel string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(rel))
+	return int64(h.Sum64() & (1<<63 - 1))
+}
+
+func escapeMediaPath(rel string) string {
+	parts := strings.Split(rel, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
+
+// MediaHandler sert les extraits audio de la bibliothèque locale sous /media/
+
+func MediaHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Méthode non autorisée.", http.StatusMethodNotAllowed)
+		return
+	}
+	lib := GetLocalLibrary()
+	if lib == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	rel := cleanManifestPath(strings.TrimPrefix(r.URL.Path, mediaRoutePrefix))
+	contentType, ok := localAudioExtensions[strings.ToLower(path.Ext(rel))]
+	if rel == "" || !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	full := filepath.Join(lib.Dir, filepath.FromSlash(rel))
+	f, err := os.Open(full)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+}
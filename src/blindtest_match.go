@@ -35,10 +35,238 @@ func normalizeGuess(s string) string {
 	return strings.TrimSpace(b.String())
 }
 
-func isCorrectGuess(guess, title string) bool {
+// GuessMatch indique quels champs d'une piste une réponse a trouvés
+type GuessMatch struct {
+	Title  bool `json:"title"`
+	Artist bool `json:"artist"`
+}
+
+func (m GuessMatch) Any() bool {
+	return m.Title || m.Artist
+}
+
+// Mots qui marquent le début d'un featuring dans un titre ou un nom d'artiste
+// ("avec" n'en fait pas partie : "Danse avec les loups" est un titre entier)
+var featMarkers = map[string]bool{"feat": true, "ft": true, "featuring": true}
+
+// stripBrackets retire tout ce qui est entre () ou [] : "(Remastered 2011)", "[Live]"...
+func stripBrackets(s string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range s {
+		switch r {
+		case '(', '[':
+			depth++
+			continue
+		case ')', ']':
+			if depth > 0 {
+				depth--
+			}
+			continue
+		}
+		if depth == 0 {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// cutFeat coupe un texte normalisé au premier "feat"/"ft"/"featuring"
+func cutFeat(normalized string) string {
+	main, _ := splitFeat(normalized)
+	return main
+}
+
+// splitFeat sépare un texte normalisé en partie principale et artistes invités
+func splitFeat(normalized string) (main, featured string) {
+	words := strings.Fields(normalized)
+	for i, w := range words {
+		if featMarkers[w] && i > 0 {
+			return strings.Join(words[:i], " "), strings.Join(words[i+1:], " ")
+		}
+	}
+	return normalized, ""
+}
+
+// titleCandidates renvoie les formes acceptées d'un titre : brut, sans suffixe " - Remastered", sans parenthèses, sans feat.
+func titleCandidates(title string) []string {
+	raw := title
+	if before, _, ok := strings.Cut(raw, " - "); ok {
+		raw = before
+	}
+	forms := []string{
+		normalizeGuess(title),
+		cutFeat(normalizeGuess(stripBrackets(title))),
+		cutFeat(normalizeGuess(stripBrackets(raw))),
+	}
+	return uniqueNonEmpty(forms)
+}
+
+// artistCandidates accepte le nom complet, et pour "A feat. B" l'artiste principal et l'invité.
+// Les noms de groupe ne sont jamais découpés ("Mumford & Sons", "Earth, Wind & Fire").
+func artistCandidates(artist string) []string {
+	main, featured := splitFeat(normalizeGuess(stripBrackets(artist)))
+	return uniqueNonEmpty([]string{normalizeGuess(artist), main, featured})
+}
+
+func uniqueNonEmpty(in []string) []string {
+	seen := map[string]bool{}
+	out := make([]string, 0, len(in))
+	for _, s := range in {
+		s = strings.TrimSpace(s)
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		out = append(out, s)
+	}
+	return out
+}
+
+// allowedTypos : tolérance proportionnelle à la longueur (0 faute sous 4 lettres, puis ~1 faute / 5 lettres)
+func allowedTypos(n int) int {
+	switch {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return n / 5
+	}
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 {
+		return len(rb)
+	}
+	if len(rb) == 0 {
+		return len(ra)
+	}
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// fuzzyEqual compare deux textes normalisés en tolérant quelques fautes de frappe
+func fuzzyEqual(guess, expected string) bool {
+	if guess == "" || expected == "" {
+		return false
+	}
+	if guess == expected {
+		return true
+	}
+	// on compare sans espaces pour ne pas pénaliser "acdc" vs "ac dc"
+	g := strings.ReplaceAll(guess, " ", "")
+	e := strings.ReplaceAll(expected, " ", "")
+	if g == e {
+		return true
+	}
+	return levenshtein(g, e) <= allowedTypos(len([]rune(e)))
+}
+
+func matchesAny(guess string, candidates []string) bool {
+	for _, c := range candidates {
+		if fuzzyEqual(guess, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchTrackGuess vérifie une réponse contre une piste, réponses alternatives comprises
+func MatchTrackGuess(guess string, t BlindtestTrack) GuessMatch {
+	titles, artists := trackCandidates(t)
+	return matchCandidates(guess, titles, artists)
+}
+
+// trackCandidates ajoute aux formes du titre et de l'artiste celles des réponses alternatives
+func trackCandidates(t BlindtestTrack) (titles, artists []string) {
+	titles = titleCandidates(t.Title)
+	for _, alt := range t.AltTitles {
+		titles = append(titles, titleCandidates(alt)...)
+	}
+	artists = artistCandidates(t.Artist)
+	for _, alt := range t.AltArtists {
+		artists = append(artists, artistCandidates(alt)...)
+	}
+	return uniqueNonEmpty(titles), uniqueNonEmpty(artists)
+}
+
+func matchCandidates(guess string, titles, artists []string) GuessMatch {
 	g := normalizeGuess(guess)
 	if g == "" {
+		return GuessMatch{}
+	}
+
+	var m GuessMatch
+	m.Title = matchesAny(g, titles)
+	m.Artist = matchesAny(g, artists)
+	if m.Title || m.Artist {
+		return m
+	}
+
+	// réponse combinée : on teste chaque découpage en deux morceaux
+	words := strings.Fields(g)
+	for k := 1; k < len(words); k++ {
+		left := strings.Join(words[:k], " ")
+		right := strings.Join(words[k:], " ")
+		if (matchesAny(left, titles) && matchesAny(right, artists)) ||
+			(matchesAny(right, titles) && matchesAny(left, artists)) {
+			return GuessMatch{Title: true, Artist: true}
+		}
+	}
+
+	// sinon on garde la moitié qui correspond à quelque chose (ex: artiste + titre faux)
+	for k := 1; k < len(words); k++ {
+		left := strings.Join(words[:k], " ")
+		right := strings.Join(words[k:], " ")
+		m.Title = m.Title || matchesAny(left, titles) || matchesAny(right, titles)
+		m.Artist = m.Artist || matchesAny(left, artists) || matchesAny(right, artists)
+	}
+	return m
+}
+
+// closeTo : la réponse n'est pas acceptée mais n'est pas loin (environ deux fois la tolérance)
+func closeTo(guess, expected string) bool {
+	if guess == "" || expected == "" {
 		return false
 	}
-	return g == normalizeGuess(title)
+	g := strings.ReplaceAll(guess, " ", "")
+	e := strings.ReplaceAll(expected, " ", "")
+	return levenshtein(g, e) <= 2*allowedTypos(len([]rune(e)))+1
+}
+
+// IsCloseTrackGuess sert à renvoyer l'indice "close" quand une réponse ratée est proche du titre,
+// de l'artiste ou d'une réponse alternative
+func IsCloseTrackGuess(guess string, t BlindtestTrack) bool {
+	titles, artists := trackCandidates(t)
+	return closeToAny(guess, append(titles, artists...))
+}
+
+func closeToAny(guess string, candidates []string) bool {
+	g := normalizeGuess(guess)
+	if g == "" {
+		return false
+	}
+	for _, c := range candidates {
+		if closeTo(g, c) {
+			return true
+		}
+	}
+	return false
 }
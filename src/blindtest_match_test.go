@@ -0,0 +1,47 @@
+package server
+
+import "testing"
+
+func TestMatchTrackGuess(t *testing.T) {
+	tests := []struct {
+		name   string
+		guess  string
+		track  BlindtestTrack
+		title  bool
+		artist bool
+	}{
+		{"titre exact", "Bohemian Rhapsody", BlindtestTrack{Title: "Bohemian Rhapsody", Artist: "Queen"}, true, false},
+		{"faute de frappe", "bohemian rapsody", BlindtestTrack{Title: "Bohemian Rhapsody", Artist: "Queen"}, true, false},
+		{"artiste seul", "queen", BlindtestTrack{Title: "Bohemian Rhapsody", Artist: "Queen"}, false, true},
+		{"titre et artiste", "bohemian rhapsody queen", BlindtestTrack{Title: "Bohemian Rhapsody", Artist: "Queen"}, true, true},
+		{"suffixe remaster", "hey jude", BlindtestTrack{Title: "Hey Jude - Remastered 2015", Artist: "The Beatles"}, true, false},
+		{"parenthèses", "yesterday", BlindtestTrack{Title: "Yesterday (Live)", Artist: "The Beatles"}, true, false},
+
+		{"avec fait partie du titre", "danse", BlindtestTrack{Title: "Danse avec les loups", Artist: "Ennio"}, false, false},
+		{"titre avec avec", "danse avec les loups", BlindtestTrack{Title: "Danse avec les loups", Artist: "Ennio"}, true, false},
+
+		{"groupe avec &", "sons", BlindtestTrack{Title: "Little Lion Man", Artist: "Mumford & Sons"}, false, false},
+		{"groupe avec & complet", "mumford & sons", BlindtestTrack{Title: "Little Lion Man", Artist: "Mumford & Sons"}, false, true},
+		{"groupe avec virgule", "wind", BlindtestTrack{Title: "September", Artist: "Earth, Wind & Fire"}, false, false},
+		{"groupe avec virgule complet", "earth wind & fire", BlindtestTrack{Title: "September", Artist: "Earth, Wind & Fire"}, false, true},
+		{"groupe avec et", "simon", BlindtestTrack{Title: "The Boxer", Artist: "Simon et Garfunkel"}, false, false},
+
+		{"feat artiste principal", "daft punk", BlindtestTrack{Title: "Get Lucky", Artist: "Daft Punk feat. Pharrell Williams"}, false, true},
+		{"feat artiste invité", "pharrell williams", BlindtestTrack{Title: "Get Lucky", Artist: "Daft Punk feat. Pharrell Williams"}, false, true},
+		{"feat dans le titre", "get lucky", BlindtestTrack{Title: "Get Lucky (feat. Pharrell Williams)", Artist: "Daft Punk"}, true, false},
+
+		{"titre alternatif", "hbfs", BlindtestTrack{Title: "Harder, Better, Faster, Stronger", Artist: "Daft Punk", AltTitles: []string{"HBFS"}}, true, false},
+		{"artiste alternatif", "johnny", BlindtestTrack{Title: "Allumer le feu", Artist: "Johnny Hallyday", AltArtists: []string{"Johnny"}}, false, true},
+		{"réponse vide", "  ", BlindtestTrack{Title: "Bohemian Rhapsody", Artist: "Queen"}, false, false},
+		{"mauvaise réponse", "stairway to heaven", BlindtestTrack{Title: "Bohemian Rhapsody", Artist: "Queen"}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MatchTrackGuess(tt.guess, tt.track)
+			if got.Title != tt.title || got.Artist != tt.artist {
+				t.Errorf("MatchTrackGuess(%q, %q / %q) = %+v, attendu titre=%v artiste=%v",
+					tt.guess, tt.track.Title, tt.track.Artist, got, tt.title, tt.artist)
+			}
+		})
+	}
+}
@@ -2,7 +2,10 @@ package server
 
 import (
 	"context"
+	"errors"
+	"log"
 	"math/rand"
+	"sort"
 	"sync"
 	"time"
 )
@@ -11,17 +14,54 @@ func init() {
 	rand.Seed(time.Now().UnixNano())
 }
 
+// bonus quand une même réponse trouve le titre ET l'artiste
+const blindtestBothBonus = 5
+
+var ErrBlindtestScoreNotSaved = errors.New("points non enregistrés, réessaie")
+
+// BlindtestAttempt garde la trace d'un essai (sans jamais renvoyer la bonne réponse)
+type BlindtestAttempt struct {
+	Guess     string         `json:"guess"`
+	Matched   GuessMatch     `json:"matched"`
+	Hint      string         `json:"hint"` // "correct"|"partial"|"close"|"wrong"
+	Points    int            `json:"points"`
+	Breakdown ScoreBreakdown `json:"breakdown"`
+	Elapsed   int64          `json:"elapsed_ms"` // depuis le début de la manche
+}
+
+// blindtestPlayerRound : essais d'un joueur pendant la manche en cours
+type blindtestPlayerRound struct {
+	found   GuessMatch
+	history []BlindtestAttempt
+	spent   int            // essais décomptés : ni les réponses proches ni les doublons ne comptent
+	score   ScoreBreakdown // cumul des points de la manche
+}
+
+// BlindtestRoundScore : détail des points d'un joueur, envoyé à la révélation
+type BlindtestRoundScore struct {
+	UserID int    `json:"user_id"`
+	Pseudo string `json:"pseudo"`
+	ScoreBreakdown
+	StreakRounds int `json:"streak_rounds"` // manches trouvées d'affilée, celle-ci comprise
+}
+
 type BlindtestGame struct {
 	roomID       int
 	totalRounds  int
 	timePerRound time.Duration
+	maxAttempts  int
+	scoring      BlindtestScoringPolicy
+	gameID       int64 // ligne "games" de l'historique (0 si non enregistrée)
+	ratings      map[int]RatingChange
 
 	mu       sync.Mutex
 	phase    string // "idle"|"playing"|"reveal"|"finished"
 	round    int
 	endsAt   time.Time
 	current  BlindtestTrack
-	attempts map[int]bool // userID -> attempted
+	attempts map[int]*blindtestPlayerRound // userID -> essais de la manche
+	streaks  map[int]int                   // userID -> manches précédentes trouvées d'affilée
+	firstBy  int                           // premier joueur à avoir trouvé le titre (0 : personne)
 	tracks   []BlindtestTrack
 	used     map[int64]bool
 	timer    *time.Timer
@@ -41,13 +81,44 @@ func GetBlindtestGame(roomID int) (*BlindtestGame, bool) {
 	return g, ok
 }
 
-func StartOrResetBlindtest(ctx context.Context, room *Room, playlistType string) (*BlindtestGame, error) {
-	tracks, err := FetchDeezerGenreTracks(ctx, playlistType)
+// StopBlindtest arrête les timers et oublie la partie (salle fermée)
+func StopBlindtest(roomID int) {
+	blindtestGamesMu.Lock()
+	g, ok := blindtestGames[roomID]
+	delete(blindtestGames, roomID)
+	blindtestGamesMu.Unlock()
+	if !ok {
+		return
+	}
+
+	g.mu.Lock()
+	if g.timer != nil {
+		g.timer.Stop()
+		g.timer = nil
+	}
+	g.phase = "finished"
+	gameID := g.gameID
+	g.mu.Unlock()
+	abandonGameRecord(gameID)
+}
+
+func StartOrResetBlindtest(ctx context.Context, room *Room, source TrackSource, settings BlindtestSettings) (*BlindtestGame, error) {
+	prev, err := beginRoomStart(ctx, room.ID)
 	if err != nil {
 		return nil, err
 	}
 
+	tracks, err := source.Tracks(ctx)
+	if err == nil && len(tracks) == 0 {
+		err = errors.New("aucune chanson jouable trouvée")
+	}
+	if err != nil {
+		abortRoomStart(room.ID, prev)
+		return nil, err
+	}
+
 	// stop propre d'une ancienne partie si elle existe
+	var oldGameID int64
 	blindtestGamesMu.Lock()
 	if old, ok := blindtestGames[room.ID]; ok {
 		old.mu.Lock()
@@ -55,32 +126,57 @@ func StartOrResetBlindtest(ctx context.Context, room *Room, playlistType string)
 			old.timer.Stop()
 			old.timer = nil
 		}
+		old.phase = "finished"
+		oldGameID = old.gameID
 		old.mu.Unlock()
 	}
 	blindtestGamesMu.Unlock()
+	abandonGameRecord(oldGameID)
+
+	if settings.MaxAttempts < minBlindtestAttempts {
+		settings.MaxAttempts = defaultBlindtestAttempts
+	}
 
 	g := &BlindtestGame{
 		roomID:       room.ID,
 		totalRounds:  room.Rounds,
 		timePerRound: time.Duration(room.TimePerRound) * time.Second,
+		maxAttempts:  settings.MaxAttempts,
+		scoring:      settings.Scoring,
 		phase:        "playing",
 		round:        0,
-		attempts:     map[int]bool{},
+		attempts:     map[int]*blindtestPlayerRound{},
+		streaks:      map[int]int{},
 		tracks:       tracks,
 		used:         map[int64]bool{},
 	}
+	g.gameID = startGameRecord(ctx, room.ID, RoomTypeBlindTest, settings.Source.Genre(), map[string]any{
+		"rounds":         room.Rounds,
+		"time_per_round": room.TimePerRound,
+		"playlist":       settings.Playlist,
+		"source":         source.Name(),
+		"max_attempts":   settings.MaxAttempts,
+		"scoring":        settings.Scoring,
+	})
 
 	blindtestGamesMu.Lock()
 	blindtestGames[room.ID] = g
 	blindtestGamesMu.Unlock()
 
+	markRoomInGame(room.ID)
+
 	g.mu.Lock()
-	defer g.mu.Unlock()
-	g.startNextRoundLocked()
+	finished := g.startNextRoundLocked()
+	g.mu.Unlock()
+	if finished {
+		g.finish()
+	}
 	return g, nil
 }
 
-func (g *BlindtestGame) startNextRoundLocked() {
+// startNextRoundLocked lance la manche suivante ; renvoie true après la dernière manche,
+// l'appelant appelle alors finish une fois le verrou relâché
+func (g *BlindtestGame) startNextRoundLocked() bool {
 	if g.timer != nil {
 		g.timer.Stop()
 		g.timer = nil
@@ -89,14 +185,14 @@ func (g *BlindtestGame) startNextRoundLocked() {
 	g.round++
 	if g.round > g.totalRounds {
 		g.phase = "finished"
-		g.attempts = map[int]bool{}
+		g.attempts = map[int]*blindtestPlayerRound{}
 		g.endsAt = time.Time{}
-		getRoomHub(g.roomID).broadcast <- mustJSON(WSMessage{Type: "blindtest_finished"})
-		return
+		return true
 	}
 
 	g.phase = "playing"
-	g.attempts = map[int]bool{}
+	g.attempts = map[int]*blindtestPlayerRound{}
+	g.firstBy = 0
 
 	candidates := make([]BlindtestTrack, 0, len(g.tracks))
 	for _, t := range g.tracks {
@@ -116,7 +212,7 @@ func (g *BlindtestGame) startNextRoundLocked() {
 	g.endsAt = time.Now().Add(g.timePerRound)
 
 	// On n'envoie JAMAIS title/artist ici
-	getRoomHub(g.roomID).broadcast <- mustJSON(WSMessage{
+	publishRoom(g.roomID, mustJSON(WSMessage{
 		Type: "blindtest_round_started",
 		Payload: map[string]any{
 			"room_id":      g.roomID,
@@ -125,37 +221,149 @@ func (g *BlindtestGame) startNextRoundLocked() {
 			"ends_at_unix": g.endsAt.Unix(),
 			"preview_url":  g.current.PreviewURL,
 		},
-	})
+	}))
 
 	g.timer = time.AfterFunc(g.timePerRound, func() {
 		g.onRoundEnd()
 	})
+	return false
 }
 
-func (g *BlindtestGame) onRoundEnd() {
+// finish clôt l'historique (niveaux compris) hors du verrou : une écriture SQLite lente
+// ne bloque ni les réponses ni les timers de la salle
+func (g *BlindtestGame) finish() {
+	ratings := finishGameRecord(g.gameID)
+	markRoomFinished(g.roomID)
+
 	g.mu.Lock()
-	defer g.mu.Unlock()
+	g.ratings = ratings
+	g.mu.Unlock()
+
+	publishRoom(g.roomID, mustJSON(WSMessage{
+		Type:    "blindtest_finished",
+		Payload: map[string]any{"ratings": ratings},
+	}))
+}
+
+func (g *BlindtestGame) onRoundEnd() {
+	players, _ := ListRoomPlayers(context.Background(), g.roomID)
 
+	g.mu.Lock()
 	if g.phase != "playing" {
+		g.mu.Unlock()
 		return
 	}
 	g.phase = "reveal"
+	g.updateStreaksLocked()
+	round, subject, details, results := g.roundRecordLocked(players)
 
 	// Reveal seulement fin de timer
-	getRoomHub(g.roomID).broadcast <- mustJSON(WSMessage{
+	publishRoom(g.roomID, mustJSON(WSMessage{
 		Type: "blindtest_round_reveal",
 		Payload: map[string]any{
 			"title":  g.current.Title,
 			"artist": g.current.Artist,
+			"scores": g.roundScoresLocked(players),
 		},
-	})
+	}))
+	g.mu.Unlock()
+
+	// écrite avant la manche suivante, pour que finishGameRecord la compte
+	recordRound(g.gameID, round, subject, details, results)
 
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.phase != "reveal" {
+		return // partie arrêtée entre-temps
+	}
 	// next round après une mini pause
-	g.timer = time.AfterFunc(3*time.Second, func() {
-		g.mu.Lock()
-		defer g.mu.Unlock()
-		g.startNextRoundLocked()
-	})
+	g.timer = time.AfterFunc(3*time.Second, g.nextRound)
+}
+
+func (g *BlindtestGame) nextRound() {
+	g.mu.Lock()
+	if g.phase != "reveal" {
+		g.mu.Unlock()
+		return // partie arrêtée entre-temps
+	}
+	finished := g.startNextRoundLocked()
+	g.mu.Unlock()
+	if finished {
+		g.finish()
+	}
+}
+
+// updateStreaksLocked : la série continue pour qui a trouvé le titre, repart à zéro pour les autres
+func (g *BlindtestGame) updateStreaksLocked() {
+	streaks := map[int]int{}
+	for userID, pr := range g.attempts {
+		if pr.found.Title {
+			streaks[userID] = g.streaks[userID] + 1
+		}
+	}
+	g.streaks = streaks
+}
+
+// roundScoresLocked : points de la manche par joueur ayant proposé quelque chose, meilleurs d'abord
+func (g *BlindtestGame) roundScoresLocked(players []RoomPlayer) []BlindtestRoundScore {
+	scores := []BlindtestRoundScore{}
+	for _, p := range players {
+		pr := g.attempts[p.UserID]
+		if pr == nil || p.IsSpectator {
+			continue
+		}
+		scores = append(scores, BlindtestRoundScore{
+			UserID:         p.UserID,
+			Pseudo:         p.Pseudo,
+			ScoreBreakdown: pr.score,
+			StreakRounds:   g.streaks[p.UserID],
+		})
+	}
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].Total > scores[j].Total })
+	return scores
+}
+
+// roundRecordLocked prépare l'enregistrement de la manche (essais et points de chaque joueur),
+// écrit par recordRound une fois le verrou relâché
+func (g *BlindtestGame) roundRecordLocked(players []RoomPlayer) (int, string, map[string]any, []RoundResult) {
+	results := make([]RoundResult, 0, len(players))
+	for _, p := range players {
+		if p.IsSpectator {
+			continue
+		}
+		history := []BlindtestAttempt{}
+		points := 0
+		if pr := g.attempts[p.UserID]; pr != nil {
+			history = pr.history
+			points = pr.score.Total
+		}
+		results = append(results, RoundResult{
+			UserID: p.UserID,
+			Answer: mustJSON(history),
+			Points: points,
+		})
+	}
+	return g.round, g.current.Artist + " - " + g.current.Title, map[string]any{
+		"track_id":    g.current.TrackID,
+		"title":       g.current.Title,
+		"artist":      g.current.Artist,
+		"album":       g.current.Album,
+		"preview_url": g.current.PreviewURL,
+	}, results
+}
+
+func (g *BlindtestGame) Phase() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.phase
+}
+
+// done : le joueur a trouvé le titre ou n'a plus d'essais
+func (g *BlindtestGame) doneLocked(pr *blindtestPlayerRound) bool {
+	if pr == nil {
+		return false
+	}
+	return pr.found.Title || pr.spent >= g.maxAttempts
 }
 
 func (g *BlindtestGame) StateForUser(userID int) map[string]any {
@@ -166,13 +374,27 @@ func (g *BlindtestGame) StateForUser(userID int) map[string]any {
 	if !g.endsAt.IsZero() {
 		endsAtUnix = g.endsAt.Unix()
 	}
+	pr := g.attempts[userID]
+	used := 0
+	history := []BlindtestAttempt{}
+	found := GuessMatch{}
+	if pr != nil {
+		used = pr.spent
+		history = pr.history
+		found = pr.found
+	}
 	st := map[string]any{
 		"phase":         g.phase,
 		"round":         g.round,
 		"total_rounds":  g.totalRounds,
 		"ends_at_unix":  endsAtUnix,
 		"preview_url":   "",
-		"already_tried": g.attempts[userID],
+		"already_tried": g.doneLocked(pr),
+		"max_attempts":  g.maxAttempts,
+		"attempts_used": used,
+		"attempts_left": max(0, g.maxAttempts-used),
+		"found":         found,
+		"history":       history,
 	}
 
 	if g.phase == "playing" {
@@ -182,41 +404,160 @@ func (g *BlindtestGame) StateForUser(userID int) map[string]any {
 		st["title"] = g.current.Title
 		st["artist"] = g.current.Artist
 	}
+	if g.phase == "finished" {
+		st["ratings"] = g.ratings
+	}
 	return st
 }
 
+// SubmitGuess évalue une réponse sous le verrou ; les points et l'annonce « a trouvé » sont
+// écrits ensuite, hors du verrou
 func (g *BlindtestGame) SubmitGuess(ctx context.Context, roomID, userID int, guess string) (map[string]any, error) {
 	g.mu.Lock()
-	defer g.mu.Unlock()
+	res, points, found := g.submitGuessLocked(userID, guess)
+	g.mu.Unlock()
 
-	if g.phase != "playing" || time.Now().After(g.endsAt) {
-		return map[string]any{"locked": true}, nil
+	if points != 0 {
+		if err := AddScore(ctx, roomID, userID, points); err != nil {
+			log.Printf("Blindtest salle %d : %d points de %d non enregistrés : %v", roomID, points, userID, err)
+			return nil, ErrBlindtestScoreNotSaved
+		}
+		BroadcastRoomUpdated(roomID) // refresh scoreboard
 	}
-	if g.attempts[userID] {
-		return map[string]any{"already_tried": true}, nil
+	if found != nil {
+		g.broadcastPlayerFound(ctx, userID, found)
 	}
-	g.attempts[userID] = true
+	return res, nil
+}
 
-	correct := isCorrectGuess(guess, g.current.Title)
-	points := 0
+// submitGuessLocked met à jour les essais du joueur ; renvoie la réponse au joueur, les points
+// gagnés et, s'il vient de trouver quelque chose, ce qu'il faut annoncer à la salle
+func (g *BlindtestGame) submitGuessLocked(userID int, guess string) (map[string]any, int, map[string]any) {
+	if g.phase != "playing" || time.Now().After(g.endsAt) {
+		return map[string]any{"locked": true}, 0, nil
+	}
+	pr := g.attempts[userID]
+	if pr == nil {
+		pr = &blindtestPlayerRound{}
+		g.attempts[userID] = pr
+	}
+	if g.doneLocked(pr) {
+		return map[string]any{"already_tried": true, "attempts_left": 0, "found": pr.found}, 0, nil
+	}
 
-	if correct {
-		remaining := int(time.Until(g.endsAt).Seconds())
-		if remaining < 0 {
-			remaining = 0
+	// une réponse déjà proposée ne coûte pas d'essai et ne rapporte (ni ne retire) rien
+	norm := normalizeGuess(guess)
+	for _, a := range pr.history {
+		if normalizeGuess(a.Guess) == norm {
+			return map[string]any{
+				"duplicate":      true,
+				"matched":        GuessMatch{},
+				"found":          pr.found,
+				"hint":           a.Hint,
+				"points_awarded": 0,
+				"locked":         false,
+				"attempts_used":  pr.spent,
+				"attempts_left":  max(0, g.maxAttempts-pr.spent),
+				"already_tried":  false,
+			}, 0, nil
 		}
-		points = remaining
+	}
+
+	match := MatchTrackGuess(guess, g.current)
+	// on ne paie que les champs trouvés pour la première fois
+	newly := GuessMatch{
+		Title:  match.Title && !pr.found.Title,
+		Artist: match.Artist && !pr.found.Artist,
+	}
+
+	hint := "wrong"
+	switch {
+	case match.Title:
+		hint = "correct"
+	case match.Artist:
+		hint = "partial"
+	case IsCloseTrackGuess(guess, g.current):
+		hint = "close"
+	}
+
+	// "close" n'est pas pénalisé et ne coûte pas d'essai : le joueur était sur la bonne piste
+	if hint != "close" {
+		pr.spent++
+	}
+	remaining := max(0, int(time.Until(g.endsAt).Seconds()))
+	breakdown := g.scoring.Score(BlindtestScoreEvent{
+		Match:     newly,
+		Wrong:     hint == "wrong",
+		Remaining: remaining,
+		First:     newly.Title && g.firstBy == 0,
+		Streak:    g.streaks[userID],
+	})
+	points := breakdown.Total
+	if newly.Title && g.firstBy == 0 {
+		g.firstBy = userID
+	}
+	pr.score = pr.score.add(breakdown)
+
+	pr.found.Title = pr.found.Title || match.Title
+	pr.found.Artist = pr.found.Artist || match.Artist
+	pr.history = append(pr.history, BlindtestAttempt{
+		Guess:     guess,
+		Matched:   match,
+		Hint:      hint,
+		Points:    points,
+		Breakdown: breakdown,
+		Elapsed:   time.Since(g.endsAt.Add(-g.timePerRound)).Milliseconds(),
+	})
 
-		if points > 0 {
-			_ = AddScore(ctx, roomID, userID, points)
-			BroadcastRoomUpdated(roomID) // refresh scoreboard
+	var found map[string]any
+	if newly.Any() {
+		found = map[string]any{
+			"round":    g.round,
+			"user_id":  userID,
+			"title":    pr.found.Title,
+			"artist":   pr.found.Artist,
+			"attempts": len(pr.history),
 		}
 	}
 
 	return map[string]any{
-		"correct":        correct,
+		"correct":        match.Title,
+		"matched":        match,
+		"found":          pr.found,
+		"hint":           hint,
 		"points_awarded": points,
+		"breakdown":      breakdown,
 		"locked":         false,
-		"already_tried":  true,
-	}, nil
+		"attempts_used":  pr.spent,
+		"attempts_left":  max(0, g.maxAttempts-pr.spent),
+		"already_tried":  g.doneLocked(pr),
+	}, points, found
+}
+
+// broadcastPlayerFound prévient la salle qu'un joueur a trouvé quelque chose, sans dévoiler la réponse
+func (g *BlindtestGame) broadcastPlayerFound(ctx context.Context, userID int, payload map[string]any) {
+	var pseudo string
+	if err := Rekdb.QueryRowContext(ctx, SQLSelectUserPseudoByID, userID).Scan(&pseudo); err != nil {
+		return
+	}
+	payload["pseudo"] = pseudo
+	publishRoom(g.roomID, mustJSON(WSMessage{Type: "blindtest_player_found", Payload: payload}))
+}
+
+// blindtestPoints : titre = secondes restantes, artiste seul = moitié, les deux = titre + artiste + bonus
+func blindtestPoints(m GuessMatch, remaining int) int {
+	if remaining <= 0 {
+		return 0
+	}
+	points := 0
+	if m.Title {
+		points += remaining
+	}
+	if m.Artist {
+		points += remaining / 2
+	}
+	if m.Title && m.Artist {
+		points += blindtestBothBonus
+	}
+	return points
 }
@@ -0,0 +1,103 @@
+package server
+
+import "fmt"
+
+// Bornes des options de score configurables par salle
+const (
+	maxFirstBonus   = 50
+	maxStreakPct    = 100 // bonus par manche consécutive, en %
+	maxStreakRounds = 5   // la série ne compte plus au-delà
+	maxMinPoints    = 30
+	maxWrongPenalty = 20
+)
+
+// BlindtestScoringPolicy calcule les points d'une réponse ; la salle choisit ses options
+// (voir BlindtestScoring), StartOrResetBlindtest fixe la politique pour toute la partie.
+type BlindtestScoringPolicy interface {
+	Score(ev BlindtestScoreEvent) ScoreBreakdown
+}
+
+// BlindtestScoreEvent décrit une réponse au moment où elle est évaluée
+type BlindtestScoreEvent struct {
+	Match     GuessMatch // champs trouvés pour la première fois par cette réponse
+	Wrong     bool       // la réponse ne correspond à rien
+	Remaining int        // secondes restantes dans la manche
+	First     bool       // premier joueur de la manche à trouver le titre
+	Streak    int        // manches précédentes d'affilée où le joueur a trouvé le titre
+}
+
+// ScoreBreakdown détaille les points d'une réponse (renvoyé au joueur et à la révélation)
+type ScoreBreakdown struct {
+	Base    int `json:"base"`    // temps restant (titre) + moitié (artiste) + bonus titre et artiste
+	Floor   int `json:"floor"`   // complément pour atteindre le minimum
+	First   int `json:"first"`   // premier à trouver le titre
+	Streak  int `json:"streak"`  // série de manches trouvées
+	Penalty int `json:"penalty"` // mauvaise réponse (négatif)
+	Total   int `json:"total"`
+}
+
+func (b ScoreBreakdown) add(o ScoreBreakdown) ScoreBreakdown {
+	return ScoreBreakdown{
+		Base:    b.Base + o.Base,
+		Floor:   b.Floor + o.Floor,
+		First:   b.First + o.First,
+		Streak:  b.Streak + o.Streak,
+		Penalty: b.Penalty + o.Penalty,
+		Total:   b.Total + o.Total,
+	}
+}
+
+// BlindtestScoring est la politique configurable stockée dans room_blindtest_settings.
+// Toutes les options à zéro = barème historique (temps restant uniquement).
+type BlindtestScoring struct {
+	FirstBonus   int `json:"first_bonus"`
+	StreakPct    int `json:"streak_pct"`
+	MinPoints    int `json:"min_points"`
+	WrongPenalty int `json:"wrong_penalty"`
+}
+
+func (s BlindtestScoring) Validate() error {
+	check := func(name string, v, max int) error {
+		if v < 0 || v > max {
+			return fmt.Errorf("%w: %s must be between 0 and %d", ErrInvalidBlindtestSettings, name, max)
+		}
+		return nil
+	}
+	if err := check("first_bonus", s.FirstBonus, maxFirstBonus); err != nil {
+		return err
+	}
+	if err := check("streak_pct", s.StreakPct, maxStreakPct); err != nil {
+		return err
+	}
+	if err := check("min_points", s.MinPoints, maxMinPoints); err != nil {
+		return err
+	}
+	return check("wrong_penalty", s.WrongPenalty, maxWrongPenalty)
+}
+
+func (s BlindtestScoring) Score(ev BlindtestScoreEvent) ScoreBreakdown {
+	var b ScoreBreakdown
+	if ev.Wrong {
+		b.Penalty = -s.WrongPenalty
+		b.Total = b.Penalty
+		return b
+	}
+	if !ev.Match.Any() {
+		return b
+	}
+
+	b.Base = blindtestPoints(ev.Match, ev.Remaining)
+	if b.Base < s.MinPoints {
+		b.Floor = s.MinPoints - b.Base
+	}
+	if ev.Match.Title {
+		if ev.First {
+			b.First = s.FirstBonus
+		}
+		if streak := min(ev.Streak, maxStreakRounds); streak > 0 {
+			b.Streak = (b.Base + b.Floor + b.First) * s.StreakPct * streak / 100
+		}
+	}
+	b.Total = b.Base + b.Floor + b.First + b.Streak
+	return b
+}
@@ -0,0 +1,187 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// TrackSource fournit la liste des titres jouables pour une partie de Blindtest.
+// Deezer est une implémentation parmi d'autres (bibliothèque locale pour les LAN sans internet).
+type TrackSource interface {
+	Name() string
+	Tracks(ctx context.Context) ([]BlindtestTrack, error)
+}
+
+// PlaylistLocal est la valeur de playlist qui sélectionne la bibliothèque locale
+const PlaylistLocal = "Local"
+
+// Types de source stockés dans room_blindtest_settings.source_kind
+const (
+	SourceSearch   = "search"   // meilleure playlist Deezer pour un mot-clé (Rock, Rap, Pop historiques)
+	SourceGenre    = "genre"    // classement d'un genre du catalogue Deezer
+	SourcePlaylist = "playlist" // playlist Deezer précise
+	SourceAlbum    = "album"
+	SourceArtist   = "artist" // meilleurs titres d'un artiste
+	SourceLocal    = "local"  // bibliothèque locale (-media-dir)
+	SourceUser     = "user"   // playlist créée par un joueur (id de playlists)
+)
+
+var ErrInvalidBlindtestSource = errors.New("invalid blindtest source")
+
+// BlindtestSource décrit d'où viennent les titres : type + identifiant Deezer (ou mot-clé),
+// Label est le libellé affiché (nom de la playlist, de l'album…)
+type BlindtestSource struct {
+	Kind  string `json:"kind"`
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+// deezerKind indique si le type désigne un objet Deezer avec un identifiant numérique
+func deezerKind(kind string) bool {
+	switch kind {
+	case SourceGenre, SourcePlaylist, SourceAlbum, SourceArtist:
+		return true
+	}
+	return false
+}
+
+func (s BlindtestSource) Validate() error {
+	switch {
+	case s.Kind == SourceLocal:
+		return nil
+	case s.Kind == SourceSearch:
+		if strings.TrimSpace(s.ID) == "" {
+			return fmt.Errorf("%w: empty search", ErrInvalidBlindtestSource)
+		}
+		return nil
+	case deezerKind(s.Kind), s.Kind == SourceUser:
+		if _, err := strconv.ParseInt(s.ID, 10, 64); err != nil {
+			return fmt.Errorf("%w: %s id %q is not a number", ErrInvalidBlindtestSource, s.Kind, s.ID)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: unknown kind %q", ErrInvalidBlindtestSource, s.Kind)
+}
+
+// Genre renvoie le genre enregistré pour les classements : seuls un genre Deezer ou un mot-clé
+// en sont un, pas le nom d'une playlist, d'un album, d'un artiste ou la bibliothèque locale
+func (s BlindtestSource) Genre() string {
+	switch s.Kind {
+	case SourceGenre, SourceSearch:
+		return s.Label
+	}
+	return ""
+}
+
+// legacyBlindtestSource convertit une ancienne playlist libre (Rock, Rap, Pop, Local)
+func legacyBlindtestSource(playlist string) BlindtestSource {
+	playlist = strings.TrimSpace(playlist)
+	if strings.EqualFold(playlist, PlaylistLocal) {
+		return BlindtestSource{Kind: SourceLocal, Label: PlaylistLocal}
+	}
+	return BlindtestSource{Kind: SourceSearch, ID: playlist, Label: playlist}
+}
+
+// ParseBlindtestSource lit la saisie de l'admin : un identifiant numérique pour le type choisi,
+// ou un lien Deezer (https://www.deezer.com/fr/playlist/123…) qui donne lui-même le type.
+func ParseBlindtestSource(kind, ref string) (BlindtestSource, error) {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	ref = strings.TrimSpace(ref)
+	switch kind {
+	case SourceLocal:
+		return BlindtestSource{Kind: SourceLocal, Label: PlaylistLocal}, nil
+	case SourceSearch:
+		s := BlindtestSource{Kind: SourceSearch, ID: ref, Label: ref}
+		return s, s.Validate()
+	}
+
+	if strings.Contains(ref, "deezer.com/") && !strings.Contains(ref, "://") {
+		ref = "https://" + ref
+	}
+	if u, err := url.Parse(ref); err == nil && u.Host != "" {
+		// /fr/playlist/123, /playlist/123?utm=…
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if !strings.HasSuffix(u.Host, "deezer.com") || len(parts) < 2 {
+			return BlindtestSource{}, fmt.Errorf("%w: not a deezer link", ErrInvalidBlindtestSource)
+		}
+		kind, ref = parts[len(parts)-2], parts[len(parts)-1]
+	}
+	s := BlindtestSource{Kind: kind, ID: ref}
+	return s, s.Validate()
+}
+
+// DeezerGenreSource récupère les titres d'une playlist Deezer correspondant à un genre
+type DeezerGenreSource struct {
+	Genre string
+}
+
+func (s DeezerGenreSource) Name() string {
+	return "deezer:" + strings.ToLower(s.Genre)
+}
+
+func (s DeezerGenreSource) Tracks(ctx context.Context) ([]BlindtestTrack, error) {
+	return cachedDeezerTracks(ctx, s)
+}
+
+func (s DeezerGenreSource) fetchList(ctx context.Context) ([]BlindtestTrack, error) {
+	return FetchDeezerGenreTracks(ctx, s.Genre)
+}
+
+// DeezerSource récupère les titres d'un genre, d'une playlist, d'un album ou d'un artiste Deezer
+type DeezerSource struct {
+	Kind string
+	ID   int64
+}
+
+func (s DeezerSource) Name() string {
+	return fmt.Sprintf("deezer:%s:%d", s.Kind, s.ID)
+}
+
+func (s DeezerSource) Tracks(ctx context.Context) ([]BlindtestTrack, error) {
+	return cachedDeezerTracks(ctx, s)
+}
+
+func (s DeezerSource) fetchList(ctx context.Context) ([]BlindtestTrack, error) {
+	return FetchDeezerSourceTracks(ctx, s.Kind, s.ID)
+}
+
+// UserPlaylistSource joue une playlist créée par un joueur ; seuls les titres nécessaires
+// aux Rounds manches sont résolus sur Deezer
+type UserPlaylistSource struct {
+	PlaylistID int
+	Rounds     int
+}
+
+func (s UserPlaylistSource) Name() string {
+	return fmt.Sprintf("playlist:%d", s.PlaylistID)
+}
+
+func (s UserPlaylistSource) Tracks(ctx context.Context) ([]BlindtestTrack, error) {
+	return PlaylistTracksForGame(ctx, s.PlaylistID, s.Rounds)
+}
+
+// TrackSourceFor choisit la source selon le descripteur configuré dans la salle (rounds : manches de la partie)
+func TrackSourceFor(src BlindtestSource, rounds int) (TrackSource, error) {
+	if err := src.Validate(); err != nil {
+		return nil, err
+	}
+	switch src.Kind {
+	case SourceLocal:
+		lib := GetLocalLibrary()
+		if lib == nil {
+			return nil, ErrLocalLibraryDisabled
+		}
+		return lib, nil
+	case SourceSearch:
+		return DeezerGenreSource{Genre: src.ID}, nil
+	case SourceUser:
+		id, _ := strconv.Atoi(src.ID)
+		return UserPlaylistSource{PlaylistID: id, Rounds: rounds}, nil
+	}
+	id, _ := strconv.ParseInt(src.ID, 10, 64)
+	return DeezerSource{Kind: src.Kind, ID: id}, nil
+}
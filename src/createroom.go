@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
+	"time"
 )
 
 const (
@@ -29,6 +30,8 @@ var (
 	ErrInvalidRoomType        = errors.New("invalid room type")
 	ErrInvalidRoomParameters  = errors.New("invalid room parameters")
 	ErrPlayerNotFound         = errors.New("player not found")
+	ErrSpectator              = errors.New("spectators cannot play")
+	ErrPlayerBanned           = errors.New("player banned from room")
 )
 
 type RoomType string
@@ -38,6 +41,17 @@ const (
 	RoomTypePetitBac  RoomType = "petit_bac"
 )
 
+// Label renvoie le nom du jeu affiché dans les pages
+func (t RoomType) Label() string {
+	switch t {
+	case RoomTypeBlindTest:
+		return "Blind Test"
+	case RoomTypePetitBac:
+		return "Petit Bac"
+	}
+	return "Salle"
+}
+
 type Room struct {
 	ID           int
 	Code         string
@@ -46,7 +60,11 @@ type Room struct {
 	MaxPlayers   int
 	TimePerRound int
 	Rounds       int
-	Status       string
+	Status       RoomStatus
+
+	RequireReady bool // refuse le lancement tant que le quorum de joueurs prêts n'est pas atteint
+	ReadyQuorum  int  // pourcentage de joueurs prêts exigé (100 = tout le monde)
+	AutoStart    bool // lance la partie après un compte à rebours quand tout le monde est prêt
 }
 
 type RoomPlayer struct {
@@ -55,6 +73,8 @@ type RoomPlayer struct {
 	IsAdmin bool
 	IsReady bool
 	Score   int
+
+	IsSpectator bool // arrivé pendant une partie : regarde jusqu'à la suivante
 }
 
 type CreateRoomOptions struct {
@@ -70,8 +90,13 @@ type SallePageData struct {
 	Players   []RoomPlayer
 	GameLabel string
 	IsAdmin   bool
+	IsReady   bool
+
+	Ready              ReadyStatus
+	ReadyCountdownUnix int64
 
 	BlindtestPlaylist  string
+	BlindtestAttempts  int
 	PetitBacCategories []PetitBacCategory
 }
 
@@ -116,6 +141,7 @@ func CreateRoom(ctx context.Context, opts CreateRoomOptions) (*Room, error) {
 			opts.MaxPlayers,
 			opts.TimePerRound,
 			opts.Rounds,
+			string(RoomStatusLobby),
 		)
 		if err != nil {
 			// collision UNIQUE(code) -> retry
@@ -139,7 +165,7 @@ func CreateRoom(ctx context.Context, opts CreateRoomOptions) (*Room, error) {
 	}
 
 	// Créateur ajouté comme admin
-	if _, err := tx.ExecContext(ctx, SQLInsertRoomPlayerAdmin, roomID, opts.CreatorID); err != nil {
+	if _, err := tx.ExecContext(ctx, SQLInsertRoomPlayerAdmin, roomID, opts.CreatorID, time.Now().Unix()); err != nil {
 		return nil, err
 	}
 
@@ -163,19 +189,7 @@ func GetRoomByCode(ctx context.Context, code string) (*Room, error) {
 		return nil, ErrRoomNotFound
 	}
 
-	var r Room
-	var typ string
-	err := Rekdb.QueryRowContext(ctx, SQLSelectRoomByCode, code).
-		Scan(&r.ID, &r.Code, &typ, &r.CreatorID, &r.MaxPlayers, &r.TimePerRound, &r.Rounds, &r.Status)
-
-	if errors.Is(err, sql.ErrNoRows) {
-		return nil, ErrRoomNotFound
-	}
-	if err != nil {
-		return nil, err
-	}
-	r.Type = RoomType(typ)
-	return &r, nil
+	return scanRoom(Rekdb.QueryRowContext(ctx, SQLSelectRoomByCode, code))
 }
 
 func AddRoomPlayer(ctx context.Context, roomID, userID int, isAdmin bool) (*RoomPlayer, error) {
@@ -194,6 +208,26 @@ func AddRoomPlayer(ctx context.Context, roomID, userID int, isAdmin bool) (*Room
 		return nil, err
 	}
 
+	// Etat de la salle : fermée = refus, partie en cours = spectateur
+	spectator := false
+	switch {
+	case room.Status.AcceptsPlayers():
+	case room.Status.AcceptsSpectators():
+		spectator = true
+	default:
+		return nil, ErrRoomClosed
+	}
+
+	// Banni par l'admin ?
+	var banned int
+	err = tx.QueryRowContext(ctx, SQLRoomBanExists, roomID, userID).Scan(&banned)
+	if err == nil {
+		return nil, ErrPlayerBanned
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
 	// Capacité
 	var count int
 	if err := tx.QueryRowContext(ctx, SQLCountRoomPlayersByRoomID, roomID).Scan(&count); err != nil {
@@ -227,7 +261,7 @@ func AddRoomPlayer(ctx context.Context, roomID, userID int, isAdmin bool) (*Room
 		adminInt = 1
 	}
 
-	if _, err := tx.ExecContext(ctx, SQLInsertRoomPlayerMember, roomID, userID, adminInt); err != nil {
+	if _, err := tx.ExecContext(ctx, SQLInsertRoomPlayerMember, roomID, userID, adminInt, boolToInt(spectator), time.Now().Unix()); err != nil {
 		if strings.Contains(strings.ToLower(err.Error()), "unique") {
 			return nil, ErrPlayerAlreadyInRoom
 		}
@@ -244,6 +278,8 @@ func AddRoomPlayer(ctx context.Context, roomID, userID int, isAdmin bool) (*Room
 		IsAdmin: isAdmin,
 		IsReady: false,
 		Score:   0,
+
+		IsSpectator: spectator,
 	}, nil
 }
 
@@ -261,12 +297,13 @@ func ListRoomPlayers(ctx context.Context, roomID int) ([]RoomPlayer, error) {
 	var players []RoomPlayer
 	for rows.Next() {
 		var p RoomPlayer
-		var adminInt, readyInt int
-		if err := rows.Scan(&p.UserID, &p.Pseudo, &adminInt, &readyInt, &p.Score); err != nil {
+		var adminInt, readyInt, spectatorInt int
+		if err := rows.Scan(&p.UserID, &p.Pseudo, &adminInt, &readyInt, &p.Score, &spectatorInt); err != nil {
 			return nil, err
 		}
 		p.IsAdmin = adminInt == 1
 		p.IsReady = readyInt == 1
+		p.IsSpectator = spectatorInt == 1
 		players = append(players, p)
 	}
 	return players, rows.Err()
@@ -287,10 +324,22 @@ func IsUserAdminInRoom(ctx context.Context, roomID, userID int) (bool, error) {
 }
 
 func getRoomByIDTx(ctx context.Context, tx *sql.Tx, id int) (*Room, error) {
+	return scanRoom(tx.QueryRowContext(ctx, SQLSelectRoomByID, id))
+}
+
+func GetRoomByID(ctx context.Context, id int) (*Room, error) {
+	if Rekdb == nil {
+		return nil, ErrDatabaseNotInitialised
+	}
+	return scanRoom(Rekdb.QueryRowContext(ctx, SQLSelectRoomByID, id))
+}
+
+func scanRoom(row *sql.Row) (*Room, error) {
 	var r Room
 	var typ string
-	err := tx.QueryRowContext(ctx, SQLSelectRoomByID, id).
-		Scan(&r.ID, &r.Code, &typ, &r.CreatorID, &r.MaxPlayers, &r.TimePerRound, &r.Rounds, &r.Status)
+	var requireReady, autoStart int
+	err := row.Scan(&r.ID, &r.Code, &typ, &r.CreatorID, &r.MaxPlayers, &r.TimePerRound, &r.Rounds, &r.Status,
+		&requireReady, &r.ReadyQuorum, &autoStart)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrRoomNotFound
 	}
@@ -298,6 +347,8 @@ func getRoomByIDTx(ctx context.Context, tx *sql.Tx, id int) (*Room, error) {
 		return nil, err
 	}
 	r.Type = RoomType(typ)
+	r.RequireReady = requireReady == 1
+	r.AutoStart = autoStart == 1
 	return &r, nil
 }
 
@@ -337,16 +388,71 @@ func randomRoomCode() (string, error) {
 	return b.String(), nil
 }
 
-func RemoveRoomPlayer(ctx context.Context, roomID, userID int) error {
+// RoomLeave décrit les conséquences d'un départ : nouvel admin éventuel, salle vidée
+type RoomLeave struct {
+	NewAdminID int // 0 si l'admin n'a pas changé
+	Closed     bool
+}
+
+// RemoveRoomPlayer retire un joueur ; si c'était l'admin, le joueur présent depuis le plus
+// longtemps prend sa place. Une salle vide est archivée (statut "closed").
+func RemoveRoomPlayer(ctx context.Context, roomID, userID int) (RoomLeave, error) {
+	var leave RoomLeave
 	if Rekdb == nil {
-		return ErrDatabaseNotInitialised
+		return leave, ErrDatabaseNotInitialised
 	}
-	res, err := Rekdb.ExecContext(ctx, SQLDeleteRoomPlayer, roomID, userID)
+
+	tx, err := Rekdb.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return leave, err
 	}
-	if rows, _ := res.RowsAffected(); rows == 0 {
-		return ErrPlayerNotFound
+	defer tx.Rollback()
+
+	leave, prev, err := removeRoomPlayerTx(ctx, tx, roomID, userID)
+	if err != nil {
+		return leave, err
 	}
-	return nil
+	if err := tx.Commit(); err != nil {
+		return leave, err
+	}
+	if leave.Closed && prev != RoomStatusClosed {
+		broadcastRoomStatus(roomID, RoomStatusClosed, prev)
+	}
+	return leave, nil
+}
+
+// removeRoomPlayerTx fait le travail de RemoveRoomPlayer dans tx (ErrPlayerNotFound si le joueur
+// n'est pas dans la salle) ; renvoie aussi l'état de la salle avant sa fermeture éventuelle
+func removeRoomPlayerTx(ctx context.Context, tx *sql.Tx, roomID, userID int) (RoomLeave, RoomStatus, error) {
+	var leave RoomLeave
+	var adminInt int
+	if err := tx.QueryRowContext(ctx, SQLSelectIsAdminInRoom, roomID, userID).Scan(&adminInt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return leave, "", ErrPlayerNotFound
+		}
+		return leave, "", err
+	}
+
+	if _, err := tx.ExecContext(ctx, SQLDeleteRoomPlayer, roomID, userID); err != nil {
+		return leave, "", err
+	}
+
+	var nextID int
+	var prev RoomStatus
+	err := tx.QueryRowContext(ctx, SQLSelectOldestRoomPlayer, roomID).Scan(&nextID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		if prev, err = transitionRoomStatusTx(ctx, tx, roomID, RoomStatusClosed); err != nil {
+			return leave, prev, err
+		}
+		leave.Closed = true
+	case err != nil:
+		return leave, "", err
+	case adminInt == 1:
+		if _, err := tx.ExecContext(ctx, SQLSetRoomPlayerAdmin, 1, roomID, nextID); err != nil {
+			return leave, "", err
+		}
+		leave.NewAdminID = nextID
+	}
+	return leave, prev, nil
 }
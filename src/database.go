@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"database/sql"
 	"log"
 
@@ -9,7 +10,9 @@ import (
 
 var Rekdb *sql.DB
 
-func InitDB(filepath string) (*sql.DB, error) {
+// OpenDB ouvre la base et vérifie la connexion, sans toucher au schéma
+
+func OpenDB(filepath string) (*sql.DB, error) {
 	db, err := sql.Open("sqlite3", filepath)
 	if err != nil {
 		log.Printf("Erreur lors de l'ouverture de la base de données (%s) : %v\n", filepath, err)
@@ -26,8 +29,6 @@ func InitDB(filepath string) (*sql.DB, error) {
 		return nil, err
 	}
 
-	// ensuuite on execute  la commande SQL pour créer la table
-
 	log.Println("Connexion SQLite établie et vérifiée.")
 
 	// Activer les clés étrangères pour pouvoir utiliser les contraintes de clés étrangères
@@ -36,29 +37,28 @@ func InitDB(filepath string) (*sql.DB, error) {
 		db.Close()
 		return nil, err
 	}
+	return db, nil
+}
 
-	// Créer les tables
-	for name, query := range TablesSQL {
-		log.Printf("Création table '%s'...", name)
-		if _, err = db.Exec(query); err != nil {
-			log.Printf("Erreur création table '%s' : %v\n", name, err)
-			db.Close()
-			return nil, err
-		}
+func InitDB(filepath string) (*sql.DB, error) {
+	db, err := OpenDB(filepath)
+	if err != nil {
+		return nil, err
 	}
 
-	// Créer les indexes
-
-	for name, query := range IndexesSQL {
-		log.Printf("Création index '%s'...", name)
-		if _, err = db.Exec(query); err != nil {
-			log.Printf("Erreur création index '%s' : %v\n", name, err)
-			db.Close()
-			return nil, err
-		}
+	// ensuite on applique les migrations manquantes (dans l'ordre, une transaction par étape)
+	n, err := RunMigrations(context.Background(), db)
+	if err != nil {
+		log.Printf("Erreur migrations : %v\n", err)
+		db.Close()
+		return nil, err
+	}
+	if n > 0 {
+		log.Printf("%d migration(s) appliquée(s).", n)
 	}
 
 	Rekdb = db
+	SetSessionStore(NewSQLSessionStore(db))
 	log.Println("Base de données initialisée avec succès.")
 	return db, nil
 }
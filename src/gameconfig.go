@@ -4,39 +4,198 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"strings"
 )
 
+const (
+	defaultBlindtestAttempts = 3
+	minBlindtestAttempts     = 1
+	maxBlindtestAttempts     = 10
+)
+
+var ErrInvalidBlindtestSettings = errors.New("invalid blindtest settings")
+
+// BlindtestSettings regroupe la configuration Blindtest d'une salle
+type BlindtestSettings struct {
+	Playlist    string // libellé affiché, repris de Source.Label
+	Source      BlindtestSource
+	MaxAttempts int
+	Scoring     BlindtestScoring
+}
+
+// Seuils de validation d'une réponse au Petit Bac
+const (
+	PetitBacVoteMajority  = "majority"
+	PetitBacVoteTwoThirds = "two_thirds"
+	PetitBacVoteUnanimous = "unanimous"
+	PetitBacVoteAdmin     = "admin" // seul le vote de l'administrateur compte
+
+	minPetitBacPoints = -5
+	maxPetitBacPoints = 10
+)
+
+// Fin d'une manche de Petit Bac
+const (
+	PetitBacEndStop = "stop" // un joueur dit STOP, les autres ont quelques secondes pour finir
+	PetitBacEndAuto = "auto" // la manche s'arrête dès qu'un joueur a tout rempli
+
+	defaultStopGrace = 5
+	maxStopGrace     = 15
+)
+
+var ErrInvalidPetitBacSettings = errors.New("invalid petit bac settings")
+
+// PetitBacSettings regroupe le barème et les règles de validation du Petit Bac d'une salle
+type PetitBacSettings struct {
+	PointsUnique        int    `json:"points_unique"`
+	PointsShared        int    `json:"points_shared"`
+	PointsInvalid       int    `json:"points_invalid"` // réponse donnée mais refusée (négatif = pénalité)
+	VoteThreshold       string `json:"vote_threshold"`
+	SelfVote            bool   `json:"self_vote"`            // le vote d'un joueur sur sa propre réponse compte
+	NormalizeDuplicates bool   `json:"normalize_duplicates"` // "L'Éléphant" et "elephant" sont la même réponse
+	EndMode             string `json:"end_mode"`
+	StopGrace           int    `json:"stop_grace"` // secondes laissées aux autres après un STOP
+	ExcludedLetters     string `json:"excluded_letters"`
+	HardLetters         bool   `json:"hard_letters"` // K, Q, W, X, Y, Z peuvent sortir
+	LetterPool          string `json:"letter_pool"`
+	LetterSeed          int64  `json:"letter_seed"`      // graine fixe pour rejouer un tirage (0 : aléatoire)
+	DictionaryCheck     bool   `json:"dictionary_check"` // réponses connues des listes de mots validées sans vote
+}
+
+// defaultPetitBacSettings : règles historiques (2 points seul, 1 point partagé, 2/3 des votes,
+// fin de manche automatique)
+func defaultPetitBacSettings() PetitBacSettings {
+	return PetitBacSettings{
+		PointsUnique:  2,
+		PointsShared:  1,
+		VoteThreshold: PetitBacVoteTwoThirds,
+		SelfVote:      true,
+		EndMode:       PetitBacEndAuto,
+		StopGrace:     defaultStopGrace,
+		LetterPool:    PetitBacPoolWeighted,
+	}
+}
+
+func (s PetitBacSettings) Validate() error {
+	for _, p := range []int{s.PointsUnique, s.PointsShared, s.PointsInvalid} {
+		if p < minPetitBacPoints || p > maxPetitBacPoints {
+			return fmt.Errorf("%w: points must be between %d and %d", ErrInvalidPetitBacSettings, minPetitBacPoints, maxPetitBacPoints)
+		}
+	}
+	switch s.VoteThreshold {
+	case PetitBacVoteMajority, PetitBacVoteTwoThirds, PetitBacVoteUnanimous, PetitBacVoteAdmin:
+	default:
+		return fmt.Errorf("%w: unknown vote threshold %q", ErrInvalidPetitBacSettings, s.VoteThreshold)
+	}
+	if s.EndMode != PetitBacEndStop && s.EndMode != PetitBacEndAuto {
+		return fmt.Errorf("%w: unknown end mode %q", ErrInvalidPetitBacSettings, s.EndMode)
+	}
+	if s.StopGrace < 0 || s.StopGrace > maxStopGrace {
+		return fmt.Errorf("%w: stop_grace must be between 0 and %d", ErrInvalidPetitBacSettings, maxStopGrace)
+	}
+	if s.DictionaryCheck && !PetitBacDictionaryAvailable() {
+		return fmt.Errorf("%w: no word lists loaded", ErrInvalidPetitBacSettings)
+	}
+	return s.validateLetters()
+}
+
+// Nombre de catégories d'une salle Petit Bac
+const (
+	minPetitBacCategories   = 1
+	maxPetitBacCategories   = 12
+	maxPetitBacCategoryName = 40
+)
+
+var (
+	ErrInvalidCategories = errors.New("invalid categories")
+	ErrDuplicateCategory = errors.New("duplicate category")
+	ErrUnknownCategory   = errors.New("category not in room")
+)
+
 type PetitBacCategory struct {
-	ID       int
-	Name     string
-	Position int
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Position int    `json:"position"`
 }
 
-func GetBlindtestPlaylist(ctx context.Context, roomID int) (string, bool, error) {
+func GetBlindtestSettings(ctx context.Context, roomID int) (BlindtestSettings, bool, error) {
+	s := BlindtestSettings{MaxAttempts: defaultBlindtestAttempts}
 	if Rekdb == nil {
-		return "", false, ErrDatabaseNotInitialised
+		return s, false, ErrDatabaseNotInitialised
 	}
-	var playlist string
-	err := Rekdb.QueryRowContext(ctx, SQLSelectBlindtestPlaylistByRoomID, roomID).Scan(&playlist)
+	err := Rekdb.QueryRowContext(ctx, SQLSelectBlindtestSettingsByRoomID, roomID).Scan(
+		&s.Playlist, &s.Source.Kind, &s.Source.ID, &s.MaxAttempts,
+		&s.Scoring.FirstBonus, &s.Scoring.StreakPct, &s.Scoring.MinPoints, &s.Scoring.WrongPenalty,
+	)
 	if errors.Is(err, sql.ErrNoRows) {
-		return "", false, nil
+		return s, false, nil
 	}
 	if err != nil {
-		return "", false, err
+		return s, false, err
 	}
-	return playlist, true, nil
+	if s.Source.Kind == "" && strings.TrimSpace(s.Playlist) != "" {
+		// ligne sans type de source : le libellé est lu comme avant (Local ou mot-clé)
+		s.Source = legacyBlindtestSource(s.Playlist)
+	}
+	s.Source.Label = s.Playlist
+	return s, true, nil
 }
 
-func SetBlindtestPlaylist(ctx context.Context, roomID int, playlist string) error {
+func SaveBlindtestSettings(ctx context.Context, roomID int, s BlindtestSettings) error {
 	if Rekdb == nil {
 		return ErrDatabaseNotInitialised
 	}
-	playlist = strings.TrimSpace(playlist)
-	if playlist == "" {
+	if s.Source.Kind == "" {
+		s.Source = legacyBlindtestSource(s.Playlist)
+	}
+	if err := s.Source.Validate(); err != nil {
+		return err
+	}
+	s.Playlist = strings.TrimSpace(s.Source.Label)
+	if s.Playlist == "" {
+		s.Playlist = s.Source.ID
+	}
+	if s.Playlist == "" {
 		return errors.New("playlist requise")
 	}
-	_, err := Rekdb.ExecContext(ctx, SQLUpsertBlindtestPlaylist, roomID, playlist)
+	if s.MaxAttempts < minBlindtestAttempts || s.MaxAttempts > maxBlindtestAttempts {
+		return fmt.Errorf("%w: max_attempts must be between %d and %d", ErrInvalidBlindtestSettings, minBlindtestAttempts, maxBlindtestAttempts)
+	}
+	if err := s.Scoring.Validate(); err != nil {
+		return err
+	}
+	_, err := Rekdb.ExecContext(ctx, SQLUpsertBlindtestSettings, roomID, s.Playlist, s.Source.Kind, s.Source.ID, s.MaxAttempts,
+		s.Scoring.FirstBonus, s.Scoring.StreakPct, s.Scoring.MinPoints, s.Scoring.WrongPenalty)
+	return err
+}
+
+func GetPetitBacSettings(ctx context.Context, roomID int) (PetitBacSettings, error) {
+	s := defaultPetitBacSettings()
+	if Rekdb == nil {
+		return s, ErrDatabaseNotInitialised
+	}
+	err := Rekdb.QueryRowContext(ctx, SQLSelectPetitBacSettingsByRoomID, roomID).Scan(
+		&s.PointsUnique, &s.PointsShared, &s.PointsInvalid, &s.VoteThreshold, &s.SelfVote, &s.NormalizeDuplicates,
+		&s.EndMode, &s.StopGrace, &s.ExcludedLetters, &s.HardLetters, &s.LetterPool, &s.LetterSeed, &s.DictionaryCheck,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s, nil
+	}
+	return s, err
+}
+
+func SavePetitBacSettings(ctx context.Context, roomID int, s PetitBacSettings) error {
+	if Rekdb == nil {
+		return ErrDatabaseNotInitialised
+	}
+	s.ExcludedLetters = normalizeLetters(s.ExcludedLetters)
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	_, err := Rekdb.ExecContext(ctx, SQLUpsertPetitBacSettings, roomID,
+		s.PointsUnique, s.PointsShared, s.PointsInvalid, s.VoteThreshold, s.SelfVote, s.NormalizeDuplicates,
+		s.EndMode, s.StopGrace, s.ExcludedLetters, s.HardLetters, s.LetterPool, s.LetterSeed, s.DictionaryCheck)
 	return err
 }
 
@@ -73,55 +232,123 @@ func EnsureDefaultPetitBacCategories(ctx context.Context, roomID int) error {
 		return nil
 	}
 
-	defaults := []string{
-		"Artiste",
-		"Album",
-		"Groupe de musique",
-		"Instrument de musique",
-		"Featuring",
-	}
-
-	for i, name := range defaults {
-		if _, err := Rekdb.ExecContext(ctx, SQLInsertPetitBacCategory, roomID, name, i+1); err != nil {
-			return err
-		}
+	// les nouvelles salles reçoivent le modèle fourni « Musique »
+	var presetID int
+	if err := Rekdb.QueryRowContext(ctx, SQLSelectBuiltinPetitBacPreset, defaultPetitBacPreset).Scan(&presetID); err != nil {
+		return err
 	}
-	return nil
+	_, err := Rekdb.ExecContext(ctx, SQLCopyPetitBacPresetToRoom, roomID, presetID)
+	return err
 }
 
-func AddPetitBacCategory(ctx context.Context, roomID int, name string) error {
+// ReplacePetitBacCategories remplace d'un coup la liste ordonnée des catégories de la salle :
+// ID connu = catégorie conservée (renommée si besoin), ID 0 = nouvelle, absente = supprimée.
+// Les positions sont renumérotées de 1 à n dans une seule transaction.
+func ReplacePetitBacCategories(ctx context.Context, roomID int, cats []PetitBacCategory) ([]PetitBacCategory, error) {
 	if Rekdb == nil {
-		return ErrDatabaseNotInitialised
+		return nil, ErrDatabaseNotInitialised
+	}
+	if len(cats) < minPetitBacCategories || len(cats) > maxPetitBacCategories {
+		return nil, fmt.Errorf("%w: %d categories (%d-%d allowed)", ErrInvalidCategories, len(cats), minPetitBacCategories, maxPetitBacCategories)
 	}
-	name = strings.TrimSpace(name)
-	if name == "" {
-		return errors.New("nom de catégorie requis")
+	names := make(map[string]bool, len(cats))
+	ids := make(map[int]bool, len(cats))
+	out := make([]PetitBacCategory, len(cats))
+	for i, c := range cats {
+		c.Name = strings.TrimSpace(c.Name)
+		if c.Name == "" || len([]rune(c.Name)) > maxPetitBacCategoryName {
+			return nil, fmt.Errorf("%w: category name must be 1-%d characters", ErrInvalidCategories, maxPetitBacCategoryName)
+		}
+		key := normalizeGuess(c.Name)
+		if names[key] {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateCategory, c.Name)
+		}
+		names[key] = true
+		if c.ID != 0 {
+			if ids[c.ID] {
+				return nil, fmt.Errorf("%w: id %d listed twice", ErrInvalidCategories, c.ID)
+			}
+			ids[c.ID] = true
+		}
+		c.Position = i + 1
+		out[i] = c
+	}
+	tx, err := Rekdb.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
 	}
+	defer tx.Rollback()
 
-	var count int
-	if err := Rekdb.QueryRowContext(ctx, SQLCountPetitBacCategoriesByRoomID, roomID).Scan(&count); err != nil {
-		return err
+	if err := ensureRoomEditableTx(ctx, tx, roomID); err != nil {
+		return nil, err
 	}
-	_, err := Rekdb.ExecContext(ctx, SQLInsertPetitBacCategory, roomID, name, count+1)
-	return err
-}
 
-func UpdatePetitBacCategoryName(ctx context.Context, roomID, id int, name string) error {
-	if Rekdb == nil {
-		return ErrDatabaseNotInitialised
+	rows, err := tx.QueryContext(ctx, SQLListPetitBacCategoriesByRoomID, roomID)
+	if err != nil {
+		return nil, err
+	}
+	existing := map[int]bool{}
+	for rows.Next() {
+		var c PetitBacCategory
+		if err := rows.Scan(&c.ID, &c.Name, &c.Position); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		existing[c.ID] = true
 	}
-	name = strings.TrimSpace(name)
-	if name == "" {
-		return errors.New("nom de catégorie requis")
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
-	_, err := Rekdb.ExecContext(ctx, SQLUpdatePetitBacCategory, name, id, roomID)
-	return err
+	for id := range ids {
+		if !existing[id] {
+			return nil, fmt.Errorf("%w: %d", ErrUnknownCategory, id)
+		}
+	}
+	for id := range existing {
+		if !ids[id] {
+			if _, err := tx.ExecContext(ctx, SQLDeletePetitBacCategory, id, roomID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// positions négatives le temps de renuméroter, sinon l'index unique (room_id, position) bloque
+	if _, err := tx.ExecContext(ctx, SQLNegatePetitBacCategoryPositions, roomID); err != nil {
+		return nil, err
+	}
+	for i, c := range out {
+		if c.ID != 0 {
+			if _, err := tx.ExecContext(ctx, SQLMovePetitBacCategory, c.Name, c.Position, c.ID, roomID); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		res, err := tx.ExecContext(ctx, SQLInsertPetitBacCategory, roomID, c.Name, c.Position)
+		if err != nil {
+			return nil, err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		out[i].ID = int(id)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-func DeletePetitBacCategory(ctx context.Context, roomID, id int) error {
-	if Rekdb == nil {
-		return ErrDatabaseNotInitialised
+// ensureRoomEditableTx refuse de toucher aux catégories pendant une partie (elles sont relues à chaque
+// manche) ; lu dans la transaction qui les modifie, pour qu'une partie ne démarre pas entre-temps
+func ensureRoomEditableTx(ctx context.Context, tx *sql.Tx, roomID int) error {
+	var status RoomStatus
+	if err := tx.QueryRowContext(ctx, SQLSelectRoomStatus, roomID).Scan(&status); err != nil {
+		return err
 	}
-	_, err := Rekdb.ExecContext(ctx, SQLDeletePetitBacCategory, id, roomID)
-	return err
+	if status == RoomStatusStarting || status == RoomStatusInGame {
+		return ErrRoomNotEditable
+	}
+	return nil
 }
@@ -0,0 +1,255 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+const (
+	historyWriteTimeout = 5 * time.Second
+	defaultHistoryLimit = 10
+	maxHistoryLimit     = 50
+)
+
+// GameHistory est une partie terminée (ou interrompue) telle que renvoyée par /api/salle/{code}/history
+type GameHistory struct {
+	ID        int64                `json:"id"`
+	Type      RoomType             `json:"type"`
+	StartedAt int64                `json:"started_at"`
+	EndedAt   int64                `json:"ended_at,omitempty"`
+	Finished  bool                 `json:"finished"`
+	Aborted   bool                 `json:"aborted,omitempty"` // relancée ou salle fermée en cours de partie
+	Settings  json.RawMessage      `json:"settings"`
+	Rounds    []RoundHistory       `json:"rounds"`
+	Totals    map[int]int          `json:"totals"` // userID -> points de la partie
+	Ratings   map[int]RatingChange `json:"ratings,omitempty"`
+}
+
+type RoundHistory struct {
+	id      int64
+	Round   int             `json:"round"`
+	Subject string          `json:"subject"` // "Artiste - Titre" ou la lettre
+	Details json.RawMessage `json:"details"`
+	EndedAt int64           `json:"ended_at"`
+	Results []RoundResult   `json:"results"`
+}
+
+// RoundResult : ce qu'un joueur a proposé pendant une manche et ce que ça lui a rapporté
+type RoundResult struct {
+	UserID int             `json:"user_id"`
+	Pseudo string          `json:"pseudo,omitempty"`
+	Answer json.RawMessage `json:"answer"`
+	Votes  json.RawMessage `json:"votes"`
+	Points int             `json:"points"`
+}
+
+// startGameRecord ouvre l'historique d'une partie ; 0 si l'écriture échoue (la partie continue quand même)
+// genre : genre du Blind Test (vide pour le Petit Bac et les sources qui n'en sont pas), sert aux classements par genre
+func startGameRecord(ctx context.Context, roomID int, roomType RoomType, genre string, settings any) int64 {
+	if Rekdb == nil {
+		return 0
+	}
+	res, err := Rekdb.ExecContext(ctx, SQLInsertGame, roomID, string(roomType), genre, time.Now().Unix(), string(mustJSON(settings)))
+	if err != nil {
+		log.Printf("Historique : création partie salle %d : %v", roomID, err)
+		return 0
+	}
+	id, _ := res.LastInsertId()
+	return id
+}
+
+// finishGameRecord clôt la partie, fige le score et la place de chaque joueur (game_players)
+// puis met à jour les classements et leur niveau ; renvoie les changements de niveau par joueur.
+func finishGameRecord(gameID int64) map[int]RatingChange {
+	if Rekdb == nil || gameID == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), historyWriteTimeout)
+	defer cancel()
+
+	var changes map[int]RatingChange
+	err := func() error {
+		tx, err := Rekdb.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		now := time.Now()
+		if _, err := tx.ExecContext(ctx, SQLFinishGame, now.Unix(), gameID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, SQLInsertGamePlayers, gameID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, SQLRankGamePlayers, gameID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, SQLAddGameToLeaderboard, weekStart(now).Unix(), gameID); err != nil {
+			return err
+		}
+		if changes, err = updateRatingsTx(ctx, tx, gameID); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}()
+	if err != nil {
+		log.Printf("Historique : fin partie %d : %v", gameID, err)
+		return nil
+	}
+	return changes
+}
+
+// abandonGameRecord clôt une partie interrompue (relancée, salle fermée) : ses manches restent dans
+// l'historique de la salle mais elle ne compte ni dans les profils, ni dans les classements, ni pour le niveau.
+// Sans effet sur une partie déjà terminée.
+func abandonGameRecord(gameID int64) {
+	if Rekdb == nil || gameID == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), historyWriteTimeout)
+	defer cancel()
+	if _, err := Rekdb.ExecContext(ctx, SQLAbandonGame, time.Now().Unix(), gameID); err != nil {
+		log.Printf("Historique : abandon partie %d : %v", gameID, err)
+	}
+}
+
+// recordRound écrit une manche et les résultats de chaque joueur dans une seule transaction
+func recordRound(gameID int64, round int, subject string, details any, results []RoundResult) {
+	if Rekdb == nil || gameID == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), historyWriteTimeout)
+	defer cancel()
+
+	err := func() error {
+		tx, err := Rekdb.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		res, err := tx.ExecContext(ctx, SQLInsertGameRound, gameID, round, subject, string(mustJSON(details)), time.Now().Unix())
+		if err != nil {
+			return err
+		}
+		roundID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		for _, rr := range results {
+			if _, err := tx.ExecContext(ctx, SQLInsertRoundResult, roundID, rr.UserID, rawOrNull(rr.Answer), rawOrNull(rr.Votes), rr.Points); err != nil {
+				return err
+			}
+		}
+		return tx.Commit()
+	}()
+	if err != nil {
+		log.Printf("Historique : manche %d partie %d : %v", round, gameID, err)
+	}
+}
+
+func rawOrNull(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return "null"
+	}
+	return string(raw)
+}
+
+// ListRoomHistory renvoie les dernières parties d'une salle, manches et résultats compris
+func ListRoomHistory(ctx context.Context, roomID, limit int) ([]GameHistory, error) {
+	if Rekdb == nil {
+		return nil, ErrDatabaseNotInitialised
+	}
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	if limit > maxHistoryLimit {
+		limit = maxHistoryLimit
+	}
+
+	rows, err := Rekdb.QueryContext(ctx, SQLListGamesByRoomID, roomID, limit)
+	if err != nil {
+		return nil, err
+	}
+	games := []GameHistory{}
+	for rows.Next() {
+		var g GameHistory
+		var endedAt sql.NullInt64
+		var settings string
+		if err := rows.Scan(&g.ID, &g.Type, &g.StartedAt, &endedAt, &g.Aborted, &settings); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		g.EndedAt = endedAt.Int64
+		g.Finished = endedAt.Valid && !g.Aborted
+		g.Settings = json.RawMessage(settings)
+		games = append(games, g)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range games {
+		if err := loadGameRounds(ctx, &games[i]); err != nil {
+			return nil, err
+		}
+		if games[i].Finished {
+			if games[i].Ratings, err = GetGameRatingChanges(ctx, games[i].ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return games, nil
+}
+
+func loadGameRounds(ctx context.Context, g *GameHistory) error {
+	rows, err := Rekdb.QueryContext(ctx, SQLListGameRoundsByGameID, g.ID)
+	if err != nil {
+		return err
+	}
+	g.Rounds = []RoundHistory{}
+	byID := map[int64]int{}
+	for rows.Next() {
+		var rh RoundHistory
+		var details string
+		if err := rows.Scan(&rh.id, &rh.Round, &rh.Subject, &details, &rh.EndedAt); err != nil {
+			rows.Close()
+			return err
+		}
+		rh.Details = json.RawMessage(details)
+		rh.Results = []RoundResult{}
+		byID[rh.id] = len(g.Rounds)
+		g.Rounds = append(g.Rounds, rh)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = Rekdb.QueryContext(ctx, SQLListRoundResultsByGameID, g.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	g.Totals = map[int]int{}
+	for rows.Next() {
+		var roundID int64
+		var rr RoundResult
+		var answer, votes string
+		if err := rows.Scan(&roundID, &rr.UserID, &rr.Pseudo, &answer, &votes, &rr.Points); err != nil {
+			return err
+		}
+		rr.Answer = json.RawMessage(answer)
+		rr.Votes = json.RawMessage(votes)
+		if idx, ok := byID[roundID]; ok {
+			g.Rounds[idx].Results = append(g.Rounds[idx].Results, rr)
+		}
+		g.Totals[rr.UserID] += rr.Points
+	}
+	return rows.Err()
+}
@@ -3,8 +3,12 @@ package server
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -59,6 +63,21 @@ func APISalleHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(parts) == 2 && parts[1] == "history" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Méthode non autorisée.", http.StatusMethodNotAllowed)
+			return
+		}
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		games, err := ListRoomHistory(r.Context(), room.ID, limit)
+		if err != nil {
+			http.Error(w, "Erreur historique.", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, games)
+		return
+	}
+
 	if len(parts) < 3 {
 		http.NotFound(w, r)
 		return
@@ -90,12 +109,20 @@ func APISalleHandler(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
+			if rejectSpectator(w, r, room.ID, userID) {
+				return
+			}
+
 			var body struct {
 				Guess string `json:"guess"`
 			}
 			_ = json.NewDecoder(r.Body).Decode(&body)
 
-			res, _ := game.SubmitGuess(r.Context(), room.ID, userID, body.Guess)
+			res, err := game.SubmitGuess(r.Context(), room.ID, userID, body.Guess)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
 			writeJSON(w, res)
 			return
 
@@ -112,7 +139,7 @@ func APISalleHandler(w http.ResponseWriter, r *http.Request) {
 				http.Error(w, "Méthode non autorisée.", http.StatusMethodNotAllowed)
 				return
 			}
-			game, ok := petitBacGames[room.ID]
+			game, ok := GetPetitBacGame(room.ID)
 			if !ok {
 				http.Error(w, "Aucune partie en cours.", http.StatusNotFound)
 				return
@@ -125,35 +152,141 @@ func APISalleHandler(w http.ResponseWriter, r *http.Request) {
 				http.Error(w, "Méthode non autorisée.", http.StatusMethodNotAllowed)
 				return
 			}
+			if rejectSpectator(w, r, room.ID, userID) {
+				return
+			}
 			var req map[int]string
 			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 				http.Error(w, "Requête invalide.", http.StatusBadRequest)
 				return
 			}
-			game, ok := petitBacGames[room.ID]
+			game, ok := GetPetitBacGame(room.ID)
 			if !ok {
 				http.Error(w, "Aucune partie en cours.", http.StatusNotFound)
 				return
 			}
-			if err := game.SubmitAnswers(userID, req); err != nil {
+			changed, err := game.SubmitAnswers(userID, req)
+			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
 			}
-			BroadcastRoomUpdated(room.ID)
+			if changed {
+				BroadcastRoomUpdated(room.ID)
+			}
 			writeJSON(w, map[string]string{"status": "ok"})
 			return
 
+		case "stop":
+			if r.Method != http.MethodPost {
+				http.Error(w, "Méthode non autorisée.", http.StatusMethodNotAllowed)
+				return
+			}
+			if rejectSpectator(w, r, room.ID, userID) {
+				return
+			}
+			// corps facultatif : dernières réponses du joueur, enregistrées avant le STOP
+			var req map[int]string
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+				http.Error(w, "Requête invalide.", http.StatusBadRequest)
+				return
+			}
+			game, ok := GetPetitBacGame(room.ID)
+			if !ok {
+				http.Error(w, "Aucune partie en cours.", http.StatusNotFound)
+				return
+			}
+			endsAt, err := game.Stop(r.Context(), userID, req)
+			if err != nil {
+				status, msg := petitBacStopError(err)
+				http.Error(w, msg, status)
+				return
+			}
+			BroadcastRoomUpdated(room.ID)
+			writeJSON(w, map[string]any{"status": "ok", "ends_at_unix": endsAt.Unix()})
+			return
+
+		case "categories":
+			// GET : liste ordonnée ; PUT : remplace toute la liste [{"id": 3, "name": "Pays"}, {"name": "Nouvelle"}] (admin)
+			switch r.Method {
+			case http.MethodGet:
+				cats, err := ListPetitBacCategories(r.Context(), room.ID)
+				if err != nil {
+					http.Error(w, "Erreur room.", http.StatusInternalServerError)
+					return
+				}
+				writeJSON(w, cats)
+			case http.MethodPut:
+				if ok, _ := IsUserAdminInRoom(r.Context(), room.ID, userID); !ok {
+					http.Error(w, "Réservé à l'administrateur.", http.StatusForbidden)
+					return
+				}
+				var req []PetitBacCategory
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					http.Error(w, "Requête invalide.", http.StatusBadRequest)
+					return
+				}
+				cats, err := ReplacePetitBacCategories(r.Context(), room.ID, req)
+				if err != nil {
+					status, msg := petitBacCategoriesError(err)
+					http.Error(w, msg, status)
+					return
+				}
+				BroadcastRoomUpdated(room.ID)
+				writeJSON(w, cats)
+			default:
+				http.Error(w, "Méthode non autorisée.", http.StatusMethodNotAllowed)
+			}
+			return
+
+		case "preset":
+			// remplace les catégories de la salle par un modèle (admin, hors partie)
+			if r.Method != http.MethodPost {
+				http.Error(w, "Méthode non autorisée.", http.StatusMethodNotAllowed)
+				return
+			}
+			if room.Type != RoomTypePetitBac {
+				http.Error(w, "Salle Petit Bac uniquement.", http.StatusBadRequest)
+				return
+			}
+			if ok, _ := IsUserAdminInRoom(r.Context(), room.ID, userID); !ok {
+				http.Error(w, "Réservé à l'administrateur.", http.StatusForbidden)
+				return
+			}
+			var body struct {
+				PresetID int `json:"preset_id"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, "Requête invalide.", http.StatusBadRequest)
+				return
+			}
+			if err := ApplyPetitBacPreset(r.Context(), room.ID, userID, body.PresetID); err != nil {
+				status, msg := petitBacPresetError(err)
+				http.Error(w, msg, status)
+				return
+			}
+			BroadcastRoomUpdated(room.ID)
+			cats, err := ListPetitBacCategories(r.Context(), room.ID)
+			if err != nil {
+				http.Error(w, "Erreur room.", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, cats)
+			return
+
 		case "votes":
 			if r.Method != http.MethodPost {
 				http.Error(w, "Méthode non autorisée.", http.StatusMethodNotAllowed)
 				return
 			}
+			if rejectSpectator(w, r, room.ID, userID) {
+				return
+			}
 			var req map[int]map[int]bool
 			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 				http.Error(w, "Requête invalide.", http.StatusBadRequest)
 				return
 			}
-			game, ok := petitBacGames[room.ID]
+			game, ok := GetPetitBacGame(room.ID)
 			if !ok {
 				http.Error(w, "Aucune partie en cours.", http.StatusNotFound)
 				return
@@ -171,6 +304,49 @@ func APISalleHandler(w http.ResponseWriter, r *http.Request) {
 	http.NotFound(w, r)
 }
 
+// petitBacStopError traduit un refus de STOP en statut HTTP et message pour le joueur
+func petitBacStopError(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrPetitBacStopDisabled):
+		return http.StatusConflict, "Le mode STOP n'est pas activé dans cette salle."
+	case errors.Is(err, ErrPetitBacNotPlaying):
+		return http.StatusConflict, "La manche est déjà terminée."
+	case errors.Is(err, ErrPetitBacIncomplete):
+		return http.StatusBadRequest, "Remplis toutes les catégories avant de dire STOP !"
+	}
+	return http.StatusInternalServerError, "Erreur lors du STOP."
+}
+
+// petitBacCategoriesError traduit un refus de la liste de catégories en statut HTTP et message pour l'admin
+func petitBacCategoriesError(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrDuplicateCategory):
+		return http.StatusBadRequest, "Deux catégories portent le même nom."
+	case errors.Is(err, ErrInvalidCategories):
+		return http.StatusBadRequest, fmt.Sprintf("Il faut entre %d et %d catégories, de %d caractères maximum.", minPetitBacCategories, maxPetitBacCategories, maxPetitBacCategoryName)
+	case errors.Is(err, ErrUnknownCategory):
+		return http.StatusConflict, "Les catégories ont changé entre-temps, recharge la page."
+	case errors.Is(err, ErrRoomNotEditable):
+		return http.StatusConflict, "Impossible de changer les catégories pendant une partie."
+	}
+	log.Printf("Catégories Petit Bac : %v", err)
+	return http.StatusInternalServerError, "Erreur lors de l'enregistrement des catégories."
+}
+
+// rejectSpectator refuse les actions de jeu aux spectateurs (arrivés en cours de partie)
+func rejectSpectator(w http.ResponseWriter, r *http.Request, roomID, userID int) bool {
+	spectator, err := IsUserSpectatorInRoom(r.Context(), roomID, userID)
+	if err != nil {
+		http.Error(w, "Erreur room.", http.StatusInternalServerError)
+		return true
+	}
+	if spectator {
+		http.Error(w, "Spectateur : tu joueras à la prochaine partie.", http.StatusForbidden)
+		return true
+	}
+	return false
+}
+
 func writeJSON(w http.ResponseWriter, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(v)
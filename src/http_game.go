@@ -2,6 +2,7 @@ package server
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 )
@@ -38,14 +39,19 @@ func StartBlindtestHandler(w http.ResponseWriter, r *http.Request, code string)
 		return
 	}
 
-	playlist, ok, err := GetBlindtestPlaylist(r.Context(), room.ID)
-	if err != nil || !ok || strings.TrimSpace(playlist) == "" {
-		http.Error(w, "Playlist non configurée.", http.StatusBadRequest)
-		return
-	}
-
-	if _, err := StartOrResetBlindtest(r.Context(), room, playlist); err != nil {
-		http.Error(w, "Erreur Deezer: "+err.Error(), http.StatusInternalServerError)
+	if err := StartRoomGame(r.Context(), room); err != nil {
+		switch {
+		case errors.Is(err, ErrPlaylistNotConfigured):
+			http.Error(w, "Playlist non configurée.", http.StatusBadRequest)
+		case errors.Is(err, ErrLocalLibraryDisabled), errors.Is(err, ErrDeezerNotFound), errors.Is(err, ErrPlaylistEmpty):
+			http.Error(w, "Playlist indisponible: "+err.Error(), http.StatusBadRequest)
+		case errors.Is(err, ErrDeezerQuota), errors.Is(err, ErrDeezerUnavailable):
+			http.Error(w, deezerErrorMessage(err), http.StatusServiceUnavailable)
+		case errors.Is(err, ErrInvalidRoomTransition):
+			http.Error(w, "Partie déjà en cours de lancement.", http.StatusConflict)
+		default:
+			http.Error(w, "Erreur "+err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -84,7 +90,11 @@ func StartPetitBacHandler(w http.ResponseWriter, r *http.Request, code string) {
 		return
 	}
 
-	if _, err := StartOrResetPetitBac(r.Context(), room); err != nil {
+	if err := StartRoomGame(r.Context(), room); err != nil {
+		if errors.Is(err, ErrInvalidRoomTransition) {
+			http.Error(w, "Partie déjà en cours de lancement.", http.StatusConflict)
+			return
+		}
 		http.Error(w, "Erreur: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -98,6 +108,17 @@ func StartGameHandler(w http.ResponseWriter, r *http.Request, code string) {
 		http.NotFound(w, r)
 		return
 	}
+
+	// Ready-check : on refuse tant que le quorum n'est pas atteint (l'admin qui lance compte comme prêt)
+	if st, err := CheckStartAllowed(r.Context(), room); err != nil {
+		if errors.Is(err, ErrPlayersNotReady) {
+			http.Error(w, fmt.Sprintf("Tous les joueurs ne sont pas prêts (%d/%d, %d requis).", st.Ready, st.Total, st.Needed), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Erreur room.", http.StatusInternalServerError)
+		return
+	}
+
 	switch room.Type {
 	case RoomTypeBlindTest:
 		StartBlindtestHandler(w, r, code)
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	LeaderboardAllTime = "all"
+	LeaderboardWeekly  = "week"
+
+	defaultLeaderboardPerPage = 20
+	maxLeaderboardPerPage     = 100
+)
+
+var ErrInvalidLeaderboard = errors.New("invalid leaderboard filter")
+
+// LeaderboardQuery décrit le classement demandé : période, jeu, genre et page
+type LeaderboardQuery struct {
+	Period  string   `json:"period"`
+	Type    RoomType `json:"type,omitempty"`
+	Genre   string   `json:"genre,omitempty"`
+	Page    int      `json:"page"`
+	PerPage int      `json:"per_page"`
+}
+
+type LeaderboardEntry struct {
+	Rank   int    `json:"rank"`
+	UserID int    `json:"user_id"`
+	Pseudo string `json:"pseudo"`
+	Points int    `json:"points"`
+	Games  int    `json:"games"`
+	Wins   int    `json:"wins"`
+}
+
+type Leaderboard struct {
+	LeaderboardQuery
+	Total   int                `json:"total"` // nombre de joueurs classés
+	Pages   int                `json:"pages"`
+	Entries []LeaderboardEntry `json:"entries"`
+}
+
+// normalize applique les valeurs par défaut et valide les filtres
+func (q *LeaderboardQuery) normalize() error {
+	switch q.Period {
+	case "":
+		q.Period = LeaderboardAllTime
+	case LeaderboardAllTime, LeaderboardWeekly:
+	default:
+		return ErrInvalidLeaderboard
+	}
+	switch q.Type {
+	case "", RoomTypeBlindTest, RoomTypePetitBac:
+	default:
+		return ErrInvalidLeaderboard
+	}
+	q.Genre = strings.TrimSpace(q.Genre)
+	if q.Genre != "" {
+		q.Type = RoomTypeBlindTest // les genres n'existent que pour le Blind Test
+	}
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PerPage <= 0 {
+		q.PerPage = defaultLeaderboardPerPage
+	}
+	if q.PerPage > maxLeaderboardPerPage {
+		q.PerPage = maxLeaderboardPerPage
+	}
+	return nil
+}
+
+// weekStart renvoie le lundi 00:00 (heure locale) de la semaine en cours
+func weekStart(now time.Time) time.Time {
+	offset := (int(now.Weekday()) + 6) % 7 // lundi = 0
+	y, m, d := now.Date()
+	return time.Date(y, m, d-offset, 0, 0, 0, 0, now.Location())
+}
+
+// scope renvoie la ligne de leaderboard_stats à lire : période, jeu et genre (vides = tous)
+func (q LeaderboardQuery) scope(now time.Time) []any {
+	var period int64
+	if q.Period == LeaderboardWeekly {
+		period = weekStart(now).Unix()
+	}
+	return []any{period, string(q.Type), q.Genre}
+}
+
+// GetLeaderboard lit une page du classement pré-agrégé selon les filtres demandés
+func GetLeaderboard(ctx context.Context, q LeaderboardQuery) (*Leaderboard, error) {
+	if Rekdb == nil {
+		return nil, ErrDatabaseNotInitialised
+	}
+	if err := q.normalize(); err != nil {
+		return nil, err
+	}
+
+	args := q.scope(time.Now())
+	lb := &Leaderboard{LeaderboardQuery: q, Entries: []LeaderboardEntry{}}
+
+	if err := Rekdb.QueryRowContext(ctx, SQLLeaderboardCount, args...).Scan(&lb.Total); err != nil {
+		return nil, err
+	}
+	lb.Pages = (lb.Total + q.PerPage - 1) / q.PerPage
+
+	offset := (q.Page - 1) * q.PerPage
+	rows, err := Rekdb.QueryContext(ctx, SQLLeaderboardSelect, append(args, q.PerPage, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e LeaderboardEntry
+		if err := rows.Scan(&e.UserID, &e.Pseudo, &e.Points, &e.Games, &e.Wins); err != nil {
+			return nil, err
+		}
+		e.Rank = offset + len(lb.Entries) + 1
+		lb.Entries = append(lb.Entries, e)
+	}
+	return lb, rows.Err()
+}
+
+// ListPlayedGenres renvoie les genres de Blind Test ayant au moins une partie terminée
+func ListPlayedGenres(ctx context.Context) ([]string, error) {
+	if Rekdb == nil {
+		return nil, ErrDatabaseNotInitialised
+	}
+	rows, err := Rekdb.QueryContext(ctx, SQLListPlayedGenres)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	genres := []string{}
+	for rows.Next() {
+		var g string
+		if err := rows.Scan(&g); err != nil {
+			return nil, err
+		}
+		genres = append(genres, g)
+	}
+	return genres, rows.Err()
+}
@@ -16,3 +16,35 @@ func IsUserInRoom(ctx context.Context, roomID, userID int) (bool, error) {
 	}
 	return err == nil, err
 }
+
+// SetPlayerReady met à jour l'état "prêt" d'un joueur dans la salle
+
+func SetPlayerReady(ctx context.Context, roomID, userID int, ready bool) error {
+	if Rekdb == nil {
+		return ErrDatabaseNotInitialised
+	}
+	readyInt := 0
+	if ready {
+		readyInt = 1
+	}
+	res, err := Rekdb.ExecContext(ctx, SQLSetRoomPlayerReady, readyInt, roomID, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrPlayerNotFound
+	}
+	return nil
+}
+
+func IsUserSpectatorInRoom(ctx context.Context, roomID, userID int) (bool, error) {
+	if Rekdb == nil {
+		return false, ErrDatabaseNotInitialised
+	}
+	var spectatorInt int
+	err := Rekdb.QueryRowContext(ctx, SQLSelectIsSpectatorInRoom, roomID, userID).Scan(&spectatorInt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return spectatorInt == 1, err
+}
@@ -0,0 +1,45 @@
+-- Schéma initial (reprend les anciennes tables TablesSQL/IndexesSQL)
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    pseudo TEXT UNIQUE NOT NULL,
+    email TEXT UNIQUE NOT NULL,
+    password_hash TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS rooms (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    code TEXT UNIQUE NOT NULL,
+    type TEXT NOT NULL,
+    creator_id INTEGER NOT NULL,
+    max_players INTEGER NOT NULL,
+    time_per_round INTEGER NOT NULL,
+    rounds INTEGER NOT NULL,
+    status TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS room_players (
+    room_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    is_admin INTEGER DEFAULT 0,
+    is_ready INTEGER DEFAULT 0,
+    score INTEGER DEFAULT 0,
+    PRIMARY KEY (room_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS room_blindtest_settings (
+    room_id INTEGER PRIMARY KEY,
+    playlist TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS room_petitbac_categories (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    room_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    position INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_rooms_owner ON rooms(creator_id);
+CREATE INDEX IF NOT EXISTS idx_room_players_room ON room_players(room_id);
+CREATE INDEX IF NOT EXISTS idx_blindtest_settings_room ON room_blindtest_settings(room_id);
+CREATE INDEX IF NOT EXISTS idx_petitbac_categories_room ON room_petitbac_categories(room_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_petitbac_categories_room_pos ON room_petitbac_categories(room_id, position);
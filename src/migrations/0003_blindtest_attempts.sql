@@ -0,0 +1,2 @@
+-- Nombre d'essais par manche de Blindtest
+ALTER TABLE room_blindtest_settings ADD COLUMN max_attempts INTEGER NOT NULL DEFAULT 3;
@@ -0,0 +1,4 @@
+-- Ready-check : quorum de joueurs prêts avant le lancement et démarrage automatique
+ALTER TABLE rooms ADD COLUMN require_ready INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE rooms ADD COLUMN ready_quorum INTEGER NOT NULL DEFAULT 100;
+ALTER TABLE rooms ADD COLUMN auto_start INTEGER NOT NULL DEFAULT 0;
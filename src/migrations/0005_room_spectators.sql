@@ -0,0 +1,2 @@
+-- Spectateurs : joueurs arrivés pendant une partie
+ALTER TABLE room_players ADD COLUMN is_spectator INTEGER NOT NULL DEFAULT 0;
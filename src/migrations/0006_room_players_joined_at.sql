@@ -0,0 +1,2 @@
+-- Ordre d'arrivée des joueurs : sert à désigner le nouvel admin quand l'admin quitte
+ALTER TABLE room_players ADD COLUMN joined_at INTEGER NOT NULL DEFAULT 0;
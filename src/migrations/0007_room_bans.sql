@@ -0,0 +1,8 @@
+-- Joueurs bannis d'une salle par l'admin
+CREATE TABLE IF NOT EXISTS room_bans (
+    room_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    banned_by INTEGER NOT NULL,
+    banned_at INTEGER NOT NULL,
+    PRIMARY KEY (room_id, user_id)
+);
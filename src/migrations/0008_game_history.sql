@@ -0,0 +1,33 @@
+-- Historique des parties : une ligne par partie, par manche et par joueur/manche
+-- aborted : partie relancée ou salle fermée avant la dernière manche (ended_at est alors renseigné aussi)
+CREATE TABLE IF NOT EXISTS games (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    room_id INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    started_at INTEGER NOT NULL,
+    ended_at INTEGER,
+    aborted INTEGER NOT NULL DEFAULT 0,
+    settings TEXT NOT NULL DEFAULT '{}'
+);
+
+CREATE TABLE IF NOT EXISTS game_rounds (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    game_id INTEGER NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+    round INTEGER NOT NULL,
+    subject TEXT NOT NULL,
+    details TEXT NOT NULL DEFAULT '{}',
+    ended_at INTEGER NOT NULL,
+    UNIQUE (game_id, round)
+);
+
+CREATE TABLE IF NOT EXISTS round_results (
+    round_id INTEGER NOT NULL REFERENCES game_rounds(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL,
+    answer TEXT NOT NULL DEFAULT 'null',
+    votes TEXT NOT NULL DEFAULT 'null',
+    points INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (round_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_games_room ON games(room_id, started_at);
+CREATE INDEX IF NOT EXISTS idx_round_results_user ON round_results(user_id);
@@ -0,0 +1,25 @@
+-- Classement final de chaque joueur par partie (écrit à la fin de la partie)
+CREATE TABLE IF NOT EXISTS game_players (
+    game_id INTEGER NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL,
+    score INTEGER NOT NULL DEFAULT 0,
+    rank INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (game_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_game_players_user ON game_players(user_id);
+
+-- parties déjà terminées avant cette migration
+INSERT OR IGNORE INTO game_players (game_id, user_id, score)
+SELECT gr.game_id, rr.user_id, SUM(rr.points)
+FROM round_results rr
+JOIN game_rounds gr ON gr.id = rr.round_id
+JOIN games g ON g.id = gr.game_id
+WHERE g.ended_at IS NOT NULL
+GROUP BY gr.game_id, rr.user_id;
+
+UPDATE game_players
+SET rank = 1 + (
+    SELECT COUNT(*) FROM game_players gp
+    WHERE gp.game_id = game_players.game_id AND gp.score > game_players.score
+);
@@ -0,0 +1,42 @@
+-- Classements : genre de la playlist (Blind Test) dénormalisé sur la partie pour être indexable
+ALTER TABLE games ADD COLUMN genre TEXT NOT NULL DEFAULT '';
+
+UPDATE games
+SET genre = COALESCE(json_extract(settings, '$.playlist'), '')
+WHERE type = 'blindtest';
+
+CREATE INDEX IF NOT EXISTS idx_games_ended ON games(ended_at);
+CREATE INDEX IF NOT EXISTS idx_games_type_ended ON games(type, ended_at);
+CREATE INDEX IF NOT EXISTS idx_games_genre_ended ON games(genre, ended_at);
+
+-- Classements pré-agrégés par joueur, mis à jour à la fin de chaque partie (finishGameRecord)
+-- period_start : 0 = depuis toujours, sinon lundi 00:00 (heure locale, unix) de la semaine
+-- type / genre : '' = tous les jeux / tous les genres ; un genre n'existe que pour le Blind Test
+CREATE TABLE IF NOT EXISTS leaderboard_stats (
+    user_id INTEGER NOT NULL,
+    period_start INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    genre TEXT NOT NULL,
+    points INTEGER NOT NULL DEFAULT 0,
+    games INTEGER NOT NULL DEFAULT 0,
+    wins INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, period_start, type, genre)
+);
+
+CREATE INDEX IF NOT EXISTS idx_leaderboard_stats_rank
+    ON leaderboard_stats(period_start, type, genre, points DESC, wins DESC);
+
+-- Reprise des parties déjà terminées
+INSERT INTO leaderboard_stats (user_id, period_start, type, genre, points, games, wins)
+SELECT gp.user_id,
+       p.k * CAST(strftime('%s', date(g.ended_at, 'unixepoch', 'localtime', 'weekday 0', '-6 days'), 'utc') AS INTEGER),
+       CASE WHEN s.k = 0 THEN '' ELSE g.type END,
+       CASE WHEN s.k = 2 THEN g.genre ELSE '' END,
+       SUM(gp.score), COUNT(*), SUM(CASE WHEN gp.rank = 1 AND gp.score > 0 THEN 1 ELSE 0 END)
+FROM game_players gp
+JOIN games g ON g.id = gp.game_id
+JOIN users u ON u.id = gp.user_id
+JOIN (SELECT 0 AS k UNION ALL SELECT 1) p
+JOIN (SELECT 0 AS k UNION ALL SELECT 1 UNION ALL SELECT 2) s
+WHERE g.ended_at IS NOT NULL AND (s.k < 2 OR g.genre <> '')
+GROUP BY 1, 2, 3, 4;
@@ -0,0 +1,22 @@
+-- Niveau (Elo multijoueur) par joueur et par jeu, mis à jour à la fin de chaque partie
+CREATE TABLE IF NOT EXISTS player_ratings (
+    user_id INTEGER NOT NULL,
+    game_type TEXT NOT NULL,
+    rating REAL NOT NULL DEFAULT 1000,
+    games INTEGER NOT NULL DEFAULT 0,
+    updated_at INTEGER NOT NULL,
+    PRIMARY KEY (user_id, game_type)
+);
+
+CREATE TABLE IF NOT EXISTS rating_history (
+    game_id INTEGER NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL,
+    game_type TEXT NOT NULL,
+    rating_before REAL NOT NULL,
+    rating_after REAL NOT NULL,
+    created_at INTEGER NOT NULL,
+    PRIMARY KEY (game_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rating_history_user ON rating_history(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_player_ratings_type ON player_ratings(game_type, rating);
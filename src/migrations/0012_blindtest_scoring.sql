@@ -0,0 +1,5 @@
+-- Barème du Blindtest par salle : bonus du premier, série, minimum de points, pénalité
+ALTER TABLE room_blindtest_settings ADD COLUMN first_bonus INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE room_blindtest_settings ADD COLUMN streak_bonus_pct INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE room_blindtest_settings ADD COLUMN min_points INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE room_blindtest_settings ADD COLUMN wrong_penalty INTEGER NOT NULL DEFAULT 0;
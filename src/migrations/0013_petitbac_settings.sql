@@ -0,0 +1,10 @@
+-- Barème et règles de validation du Petit Bac par salle (valeurs par défaut = règles historiques)
+CREATE TABLE IF NOT EXISTS room_petitbac_settings (
+    room_id INTEGER PRIMARY KEY,
+    points_unique INTEGER NOT NULL DEFAULT 2,
+    points_shared INTEGER NOT NULL DEFAULT 1,
+    points_invalid INTEGER NOT NULL DEFAULT 0,
+    vote_threshold TEXT NOT NULL DEFAULT 'two_thirds',
+    self_vote INTEGER NOT NULL DEFAULT 1,
+    normalize_duplicates INTEGER NOT NULL DEFAULT 0
+);
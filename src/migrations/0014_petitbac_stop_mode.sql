@@ -0,0 +1,4 @@
+-- Fin de manche du Petit Bac : "auto" (dès qu'un joueur a tout rempli, comportement historique)
+-- ou "stop" (un joueur dit STOP, court délai de grâce)
+ALTER TABLE room_petitbac_settings ADD COLUMN end_mode TEXT NOT NULL DEFAULT 'auto';
+ALTER TABLE room_petitbac_settings ADD COLUMN stop_grace_seconds INTEGER NOT NULL DEFAULT 5;
@@ -0,0 +1,5 @@
+-- Tirage des lettres du Petit Bac : lettres exclues, lettres difficiles, pondération et graine fixe (débogage)
+ALTER TABLE room_petitbac_settings ADD COLUMN excluded_letters TEXT NOT NULL DEFAULT '';
+ALTER TABLE room_petitbac_settings ADD COLUMN hard_letters INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE room_petitbac_settings ADD COLUMN letter_pool TEXT NOT NULL DEFAULT 'weighted';
+ALTER TABLE room_petitbac_settings ADD COLUMN letter_seed INTEGER NOT NULL DEFAULT 0;
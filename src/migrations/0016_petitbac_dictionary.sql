@@ -0,0 +1,2 @@
+-- Validation assistée par dictionnaire : les réponses connues sont validées d'office, seules les autres passent au vote
+ALTER TABLE room_petitbac_settings ADD COLUMN dictionary_check INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,52 @@
+-- Modèles de catégories du Petit Bac : modèles fournis (owner_id NULL) et modèles créés par les joueurs
+CREATE TABLE IF NOT EXISTS petitbac_presets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    owner_id INTEGER,
+    created_at INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS petitbac_preset_categories (
+    preset_id INTEGER NOT NULL REFERENCES petitbac_presets(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (preset_id, position)
+);
+
+-- un nom par joueur (et un nom unique parmi les modèles fournis)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_petitbac_presets_owner_name ON petitbac_presets(COALESCE(owner_id, 0), name);
+
+INSERT INTO petitbac_presets (name, owner_id) VALUES
+    ('Musique', NULL),
+    ('Culture générale', NULL),
+    ('Cinéma', NULL),
+    ('Géographie', NULL);
+
+INSERT INTO petitbac_preset_categories (preset_id, name, position)
+SELECT p.id, c.name, c.position
+FROM petitbac_presets p
+JOIN (
+    SELECT 'Musique' AS preset, 'Artiste' AS name, 1 AS position
+    UNION ALL SELECT 'Musique', 'Album', 2
+    UNION ALL SELECT 'Musique', 'Groupe de musique', 3
+    UNION ALL SELECT 'Musique', 'Instrument de musique', 4
+    UNION ALL SELECT 'Musique', 'Featuring', 5
+    UNION ALL SELECT 'Culture générale', 'Pays', 1
+    UNION ALL SELECT 'Culture générale', 'Prénom', 2
+    UNION ALL SELECT 'Culture générale', 'Animal', 3
+    UNION ALL SELECT 'Culture générale', 'Métier', 4
+    UNION ALL SELECT 'Culture générale', 'Fruit ou légume', 5
+    UNION ALL SELECT 'Culture générale', 'Objet', 6
+    UNION ALL SELECT 'Cinéma', 'Film', 1
+    UNION ALL SELECT 'Cinéma', 'Acteur ou actrice', 2
+    UNION ALL SELECT 'Cinéma', 'Réalisateur', 3
+    UNION ALL SELECT 'Cinéma', 'Série', 4
+    UNION ALL SELECT 'Cinéma', 'Personnage de fiction', 5
+    UNION ALL SELECT 'Géographie', 'Pays', 1
+    UNION ALL SELECT 'Géographie', 'Capitale', 2
+    UNION ALL SELECT 'Géographie', 'Ville de France', 3
+    UNION ALL SELECT 'Géographie', 'Fleuve ou rivière', 4
+    UNION ALL SELECT 'Géographie', 'Montagne', 5
+    UNION ALL SELECT 'Géographie', 'Région', 6
+) c ON c.preset = p.name
+WHERE p.owner_id IS NULL;
@@ -0,0 +1,14 @@
+-- Source des titres du Blindtest : type (search, genre, playlist, album, artist, local) + identifiant Deezer.
+-- playlist reste le libellé affiché (salle, historique, profil).
+ALTER TABLE room_blindtest_settings ADD COLUMN source_kind TEXT NOT NULL DEFAULT '';
+ALTER TABLE room_blindtest_settings ADD COLUMN source_id TEXT NOT NULL DEFAULT '';
+
+-- salles existantes : « Local » ou recherche par mot-clé (Rock, Rap, Pop)
+UPDATE room_blindtest_settings SET source_kind = 'local' WHERE lower(trim(playlist)) = 'local';
+UPDATE room_blindtest_settings SET source_kind = 'search', source_id = trim(playlist)
+WHERE source_kind = '' AND trim(playlist) <> '';
+
+-- Seuls les genres Deezer et les mots-clés (Rock, Rap, Pop…) sont des genres : les parties déjà jouées
+-- sur la bibliothèque locale n'en ont pas, ni dans l'historique ni dans les classements
+UPDATE games SET genre = '' WHERE type = 'blindtest' AND lower(trim(genre)) = 'local';
+DELETE FROM leaderboard_stats WHERE lower(trim(genre)) = 'local';
@@ -0,0 +1,36 @@
+-- Playlists de Blindtest créées par les joueurs
+CREATE TABLE IF NOT EXISTS playlists (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    owner_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    created_at INTEGER NOT NULL DEFAULT 0,
+    updated_at INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_playlists_owner_name ON playlists(owner_id, name);
+
+-- source : 'deezer' (source_ref = id du titre) ou 'local' (source_ref = chemin dans -media-dir)
+-- alt_titles / alt_artists : réponses acceptées en plus, séparées par « ; »
+CREATE TABLE IF NOT EXISTS playlist_tracks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    playlist_id INTEGER NOT NULL REFERENCES playlists(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    source TEXT NOT NULL,
+    source_ref TEXT NOT NULL,
+    title TEXT NOT NULL,
+    artist TEXT NOT NULL DEFAULT '',
+    album TEXT NOT NULL DEFAULT '',
+    alt_titles TEXT NOT NULL DEFAULT '',
+    alt_artists TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_playlist_tracks_playlist ON playlist_tracks(playlist_id, position);
+
+-- partage : les amis peuvent choisir la playlist dans leurs salles (lecture seule)
+CREATE TABLE IF NOT EXISTS playlist_shares (
+    playlist_id INTEGER NOT NULL REFERENCES playlists(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (playlist_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_playlist_shares_user ON playlist_shares(user_id);
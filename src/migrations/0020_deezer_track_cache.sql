@@ -0,0 +1,8 @@
+-- Cache des listes de titres Deezer (source_key = TrackSource.Name(), ex. deezer:playlist:123)
+-- tracks : JSON des titres jouables ; fetched_at : dernière récupération réussie (unix)
+CREATE TABLE IF NOT EXISTS deezer_track_cache (
+    source_key TEXT PRIMARY KEY,
+    tracks TEXT NOT NULL,
+    track_count INTEGER NOT NULL DEFAULT 0,
+    fetched_at INTEGER NOT NULL
+);
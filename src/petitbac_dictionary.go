@@ -0,0 +1,107 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Listes de mots du Petit Bac : un fichier .txt par catégorie dans le dossier -dict-dir,
+// nommé d'après la catégorie ("instrument de musique.txt", "pays.txt"...), une réponse par ligne, # pour commenter.
+var (
+	petitBacDictMu sync.RWMutex
+	petitBacDict   map[string]map[string]bool // catégorie normalisée -> réponses normalisées
+)
+
+// SetPetitBacDictionaryDir charge les listes de mots (dossier vide = validation par dictionnaire désactivée)
+// et renvoie le nombre de listes chargées
+func SetPetitBacDictionaryDir(dir string) (int, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		petitBacDictMu.Lock()
+		petitBacDict = nil
+		petitBacDictMu.Unlock()
+		return 0, nil
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return 0, err
+	}
+	if !info.IsDir() {
+		return 0, fmt.Errorf("%s n'est pas un dossier", dir)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return 0, err
+	}
+	dict := map[string]map[string]bool{}
+	for _, file := range files {
+		words, err := loadWordList(file)
+		if err != nil {
+			return 0, fmt.Errorf("%s : %w", filepath.Base(file), err)
+		}
+		key := dictionaryCategoryKey(strings.TrimSuffix(filepath.Base(file), ".txt"))
+		if dict[key] == nil {
+			dict[key] = map[string]bool{}
+		}
+		for w := range words {
+			dict[key][w] = true
+		}
+	}
+
+	petitBacDictMu.Lock()
+	petitBacDict = dict
+	petitBacDictMu.Unlock()
+	return len(dict), nil
+}
+
+func loadWordList(file string) (map[string]bool, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	words := map[string]bool{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if w := petitBacWordKey(line); w != "" {
+			words[w] = true
+		}
+	}
+	return words, sc.Err()
+}
+
+func PetitBacDictionaryAvailable() bool {
+	petitBacDictMu.RLock()
+	defer petitBacDictMu.RUnlock()
+	return petitBacDict != nil
+}
+
+// dictionaryCategoryKey rapproche un nom de catégorie et un nom de fichier ("Instruments_de-musique" ~ "instrument de musique")
+func dictionaryCategoryKey(name string) string {
+	name = strings.NewReplacer("_", " ", "-", " ").Replace(name)
+	words := strings.Fields(normalizeGuess(name))
+	for i, w := range words {
+		if len(w) > 3 {
+			words[i] = strings.TrimSuffix(w, "s")
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// knownPetitBacAnswer indique si la réponse figure dans la liste de la catégorie (false s'il n'y a pas de liste)
+func knownPetitBacAnswer(category, answer string) bool {
+	petitBacDictMu.RLock()
+	defer petitBacDictMu.RUnlock()
+	words := petitBacDict[dictionaryCategoryKey(category)]
+	return words[petitBacWordKey(answer)]
+}
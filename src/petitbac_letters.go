@@ -0,0 +1,115 @@
+package server
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// Tirage des lettres
+const (
+	PetitBacPoolUniform  = "uniform"  // toutes les lettres ont la même chance
+	PetitBacPoolWeighted = "weighted" // selon la fréquence des initiales en français
+
+	petitBacHardLetters = "KQWXYZ" // exclues sauf si la salle active les lettres difficiles
+)
+
+// Poids relatifs des initiales de mots en français (pool "weighted")
+var frenchInitialWeights = map[rune]int{
+	'A': 9, 'B': 6, 'C': 10, 'D': 7, 'E': 6, 'F': 5, 'G': 4, 'H': 3, 'I': 3,
+	'J': 2, 'K': 1, 'L': 6, 'M': 7, 'N': 3, 'O': 3, 'P': 9, 'Q': 1, 'R': 6,
+	'S': 8, 'T': 6, 'U': 2, 'V': 4, 'W': 1, 'X': 1, 'Y': 1, 'Z': 1,
+}
+
+// normalizeLetters garde les lettres A-Z d'une saisie libre ("w, x ; z" -> "WXZ"), sans doublon, dans l'ordre alphabétique
+func normalizeLetters(s string) string {
+	seen := map[rune]bool{}
+	for _, r := range strings.ToUpper(s) {
+		if r >= 'A' && r <= 'Z' {
+			seen[r] = true
+		}
+	}
+	var b strings.Builder
+	for r := 'A'; r <= 'Z'; r++ {
+		if seen[r] {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// letterPool renvoie les lettres jouables de la salle
+func (s PetitBacSettings) letterPool() []rune {
+	var pool []rune
+	for r := 'A'; r <= 'Z'; r++ {
+		if strings.ContainsRune(s.ExcludedLetters, r) {
+			continue
+		}
+		if !s.HardLetters && strings.ContainsRune(petitBacHardLetters, r) {
+			continue
+		}
+		pool = append(pool, r)
+	}
+	return pool
+}
+
+func (s PetitBacSettings) validateLetters() error {
+	if s.LetterPool != PetitBacPoolUniform && s.LetterPool != PetitBacPoolWeighted {
+		return fmt.Errorf("%w: unknown letter pool %q", ErrInvalidPetitBacSettings, s.LetterPool)
+	}
+	if len(s.letterPool()) == 0 {
+		return fmt.Errorf("%w: no letter left to draw", ErrInvalidPetitBacSettings)
+	}
+	return nil
+}
+
+// letterDraw tire les lettres d'une partie avec son propre générateur : même graine, mêmes lettres.
+// Aucune lettre ne ressort tant qu'il en reste d'autres à tirer.
+type letterDraw struct {
+	rng      *rand.Rand
+	pool     []rune
+	weighted bool
+	used     map[rune]bool
+}
+
+func newLetterDraw(s PetitBacSettings, seed int64) *letterDraw {
+	return &letterDraw{
+		rng:      rand.New(rand.NewSource(seed)),
+		pool:     s.letterPool(),
+		weighted: s.LetterPool == PetitBacPoolWeighted,
+		used:     map[rune]bool{},
+	}
+}
+
+func (d *letterDraw) next() string {
+	var candidates []rune
+	for _, r := range d.pool {
+		if !d.used[r] {
+			candidates = append(candidates, r)
+		}
+	}
+	// plus de manches que de lettres : on repart du pool complet
+	if len(candidates) == 0 {
+		d.used = map[rune]bool{}
+		candidates = d.pool
+	}
+
+	pick := candidates[0]
+	if d.weighted {
+		total := 0
+		for _, r := range candidates {
+			total += frenchInitialWeights[r]
+		}
+		n := d.rng.Intn(total)
+		for _, r := range candidates {
+			if n -= frenchInitialWeights[r]; n < 0 {
+				pick = r
+				break
+			}
+		}
+	} else {
+		pick = candidates[d.rng.Intn(len(candidates))]
+	}
+	d.used[pick] = true
+	return string(pick)
+}
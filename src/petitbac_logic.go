@@ -3,7 +3,6 @@ package server
 import (
 	"context"
 	"errors"
-	"math/rand"
 	"strings"
 	"sync"
 	"time"
@@ -13,6 +12,11 @@ type PetitBacGame struct {
 	roomID       int
 	totalRounds  int
 	timePerRound time.Duration
+	gameID       int64 // ligne "games" de l'historique (0 si non enregistrée)
+	ratings      map[int]RatingChange
+	settings     PetitBacSettings // barème figé au lancement de la partie
+	seed         int64            // graine du tirage des lettres (rejouer la partie à l'identique)
+	letters      *letterDraw
 
 	mu      sync.Mutex
 	phase   string // "idle" | "playing" | "validation" | "finished"
@@ -22,38 +26,106 @@ type PetitBacGame struct {
 	answers map[int]map[int]string       // userID -> catID -> answer
 	votes   map[int]map[int]map[int]bool // catID -> userID -> voterID -> bool
 	timer   *time.Timer
+	stopBy  int // joueur ayant dit STOP pendant la manche (0 : personne)
+
+	// validation par dictionnaire : réponses jugées sans vote, catID -> userID -> valide
+	prejudged map[int]map[int]bool
+	voters    []RoomPlayer // joueurs (hors spectateurs) au début du vote, lus une fois par manche
 }
 
+const (
+	petitBacValidationTime    = 30 * time.Second
+	petitBacMinValidationTime = 8 * time.Second
+	petitBacVoteTimePerAnswer = 2 * time.Second
+	petitBacNoVoteDelay       = 3 * time.Second // rien à voter : juste le temps de voir les réponses
+)
+
+var (
+	ErrPetitBacNotPlaying   = errors.New("not in playing phase")
+	ErrPetitBacStopDisabled = errors.New("stop mode disabled")
+	ErrPetitBacIncomplete   = errors.New("all categories must be filled to stop")
+)
+
 var (
 	petitBacGamesMu sync.Mutex
 	petitBacGames   = map[int]*PetitBacGame{} // roomID -> game
 )
 
+// GetPetitBacGame récupère la partie de Petit Bac associée à une salle donnée
+
+func GetPetitBacGame(roomID int) (*PetitBacGame, bool) {
+	petitBacGamesMu.Lock()
+	defer petitBacGamesMu.Unlock()
+	g, ok := petitBacGames[roomID]
+	return g, ok
+}
+
 func StartOrResetPetitBac(ctx context.Context, room *Room) (*PetitBacGame, error) {
+	prev, err := beginRoomStart(ctx, room.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := GetPetitBacSettings(ctx, room.ID)
+	if err != nil {
+		abortRoomStart(room.ID, prev)
+		return nil, err
+	}
+
 	petitBacGamesMu.Lock()
 	defer petitBacGamesMu.Unlock()
 
-	if g, ok := petitBacGames[room.ID]; ok && g.timer != nil {
-		g.timer.Stop()
+	if old, ok := petitBacGames[room.ID]; ok {
+		old.mu.Lock()
+		if old.timer != nil {
+			old.timer.Stop()
+			old.timer = nil
+		}
+		old.phase = "finished"
+		oldGameID := old.gameID
+		old.mu.Unlock()
+		abandonGameRecord(oldGameID)
+	}
+
+	seed := settings.LetterSeed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
 	}
+	letters := newLetterDraw(settings, seed)
 
 	game := &PetitBacGame{
 		roomID:       room.ID,
 		totalRounds:  room.Rounds,
 		timePerRound: time.Duration(room.TimePerRound) * time.Second,
+		settings:     settings,
+		seed:         seed,
+		letters:      letters,
 		phase:        "playing",
 		round:        1,
-		letter:       randomLetter(),
+		letter:       letters.next(),
 		answers:      map[int]map[int]string{},
 		votes:        map[int]map[int]map[int]bool{},
 	}
+	categories, _ := ListPetitBacCategories(ctx, room.ID)
+	categoryNames := make([]string, 0, len(categories))
+	for _, cat := range categories {
+		categoryNames = append(categoryNames, cat.Name)
+	}
+	game.gameID = startGameRecord(ctx, room.ID, RoomTypePetitBac, "", map[string]any{
+		"rounds":         room.Rounds,
+		"time_per_round": room.TimePerRound,
+		"categories":     categoryNames,
+		"scoring":        settings,
+		"seed":           seed,
+	})
 	game.endsAt = time.Now().Add(game.timePerRound)
 	game.timer = time.AfterFunc(game.timePerRound, func() {
 		game.onRoundEnd()
 	})
 	petitBacGames[room.ID] = game
+	markRoomInGame(room.ID)
 
-	getRoomHub(room.ID).broadcast <- mustJSON(WSMessage{
+	publishRoom(room.ID, mustJSON(WSMessage{
 		Type: "petitbac_round_started",
 		Payload: map[string]any{
 			"room_id":      game.roomID,
@@ -62,40 +134,128 @@ func StartOrResetPetitBac(ctx context.Context, room *Room) (*PetitBacGame, error
 			"ends_at_unix": game.endsAt.Unix(),
 			"letter":       game.letter,
 		},
-	})
+	}))
 
 	return game, nil
 }
 
-func randomLetter() string {
-	letters := "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
-	return string(letters[rand.Intn(len(letters))])
+// StopPetitBac arrête les timers et oublie la partie (salle fermée)
+func StopPetitBac(roomID int) {
+	petitBacGamesMu.Lock()
+	g, ok := petitBacGames[roomID]
+	delete(petitBacGames, roomID)
+	petitBacGamesMu.Unlock()
+	if !ok {
+		return
+	}
+
+	g.mu.Lock()
+	if g.timer != nil {
+		g.timer.Stop()
+		g.timer = nil
+	}
+	g.phase = "finished"
+	gameID := g.gameID
+	g.mu.Unlock()
+	abandonGameRecord(gameID)
 }
 
-func (g *PetitBacGame) SubmitAnswers(userID int, answers map[int]string) error {
+func (g *PetitBacGame) Phase() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.phase
+}
+
+func (g *PetitBacGame) SubmitAnswers(userID int, answers map[int]string) (bool, error) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 	if g.phase != "playing" {
-		return errors.New("not in playing phase")
+		return false, ErrPetitBacNotPlaying
 	}
+	prev, had := g.answers[userID]
 	g.answers[userID] = answers
+	// un brouillon qui ne change pas l'état de la grille (vide, incomplète, remplie) n'intéresse que son auteur
+	changed := !had || answersFilled(prev) != answersFilled(answers)
 
-	// Vérifier si ce joueur a rempli toutes les catégories
-	allFilled := true
-	for _, ans := range answers {
-		if strings.TrimSpace(ans) == "" {
-			allFilled = false
-			break
-		}
+	// mode STOP : simple brouillon, la manche ne s'arrête que sur un STOP explicite
+	if g.settings.EndMode == PetitBacEndStop {
+		return changed, nil
 	}
-	if allFilled {
+
+	// Vérifier si ce joueur a rempli toutes les catégories (onRoundEnd diffuse la fin de manche)
+	if answersFilled(answers) {
 		if g.timer != nil {
 			g.timer.Stop()
 			g.timer = nil
 		}
 		go g.onRoundEnd()
+		return false, nil
 	}
-	return nil
+	return changed, nil
+}
+
+// answersFilled : aucune des catégories envoyées n'est laissée vide
+func answersFilled(answers map[int]string) bool {
+	for _, ans := range answers {
+		if strings.TrimSpace(ans) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Stop : le joueur dit STOP (ses réponses éventuelles sont enregistrées avant) ; toutes ses catégories
+// doivent être remplies. Les autres ont encore StopGrace secondes, puis la manche passe au vote.
+func (g *PetitBacGame) Stop(ctx context.Context, userID int, answers map[int]string) (time.Time, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.settings.EndMode != PetitBacEndStop {
+		return time.Time{}, ErrPetitBacStopDisabled
+	}
+	if g.phase != "playing" {
+		return time.Time{}, ErrPetitBacNotPlaying
+	}
+	if answers != nil {
+		g.answers[userID] = answers
+	}
+	// déjà arrêtée : le compte à rebours en cours reste valable
+	if g.stopBy != 0 {
+		return g.endsAt, nil
+	}
+
+	categories, err := ListPetitBacCategories(ctx, g.roomID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, cat := range categories {
+		if strings.TrimSpace(g.answers[userID][cat.ID]) == "" {
+			return time.Time{}, ErrPetitBacIncomplete
+		}
+	}
+
+	g.stopBy = userID
+	if endsAt := time.Now().Add(time.Duration(g.settings.StopGrace) * time.Second); endsAt.Before(g.endsAt) {
+		g.endsAt = endsAt
+	}
+	if g.timer != nil {
+		g.timer.Stop()
+	}
+	g.timer = time.AfterFunc(time.Until(g.endsAt), func() {
+		g.onRoundEnd()
+	})
+
+	var pseudo string
+	_ = Rekdb.QueryRowContext(ctx, SQLSelectUserPseudoByID, userID).Scan(&pseudo)
+	publishRoom(g.roomID, mustJSON(WSMessage{
+		Type: "petitbac_stop",
+		Payload: map[string]any{
+			"round":        g.round,
+			"user_id":      userID,
+			"pseudo":       pseudo,
+			"ends_at_unix": g.endsAt.Unix(),
+		},
+	}))
+	return g.endsAt, nil
 }
 
 func (g *PetitBacGame) onRoundEnd() {
@@ -108,7 +268,12 @@ func (g *PetitBacGame) onRoundEnd() {
 	// S'assurer que chaque joueur a une entrée dans g.answers (même vide)
 	players, _ := ListRoomPlayers(context.Background(), g.roomID)
 	categories, _ := ListPetitBacCategories(context.Background(), g.roomID)
+	g.voters = g.voters[:0]
 	for _, p := range players {
+		if p.IsSpectator {
+			continue
+		}
+		g.voters = append(g.voters, p)
 		if g.answers[p.UserID] == nil {
 			g.answers[p.UserID] = map[int]string{}
 		}
@@ -119,15 +284,69 @@ func (g *PetitBacGame) onRoundEnd() {
 		}
 	}
 
+	validationTime := petitBacValidationTime
+	g.prejudged = map[int]map[int]bool{}
+	if g.settings.DictionaryCheck {
+		validationTime = g.prejudgeLocked(categories)
+	}
+
 	g.phase = "validation"
-	g.endsAt = time.Now().Add(30 * time.Second)
+	g.endsAt = time.Now().Add(validationTime)
 	g.votes = make(map[int]map[int]map[int]bool)
 	BroadcastRoomUpdated(g.roomID)
-	g.timer = time.AfterFunc(30*time.Second, func() {
+	g.timer = time.AfterFunc(validationTime, func() {
 		g.onValidationEnd()
 	})
 }
 
+// prejudgeLocked valide d'office les réponses connues des listes de mots et refuse celles qui sont vides
+// ou ne commencent pas par la lettre ; renvoie la durée du vote, proportionnelle aux réponses contestées.
+func (g *PetitBacGame) prejudgeLocked(categories []PetitBacCategory) time.Duration {
+	contested := 0
+	for _, cat := range categories {
+		g.prejudged[cat.ID] = map[int]bool{}
+		for userID, userAnswers := range g.answers {
+			answer := userAnswers[cat.ID]
+			switch {
+			case answer == "" || !strings.HasPrefix(strings.ToUpper(answer), g.letter):
+				g.prejudged[cat.ID][userID] = false
+			case knownPetitBacAnswer(cat.Name, answer):
+				g.prejudged[cat.ID][userID] = true
+			default:
+				contested++
+			}
+		}
+	}
+	if contested == 0 {
+		return petitBacNoVoteDelay
+	}
+	return min(petitBacValidationTime, petitBacMinValidationTime+time.Duration(contested)*petitBacVoteTimePerAnswer)
+}
+
+// allContestedVotedLocked : chaque réponse contestée a reçu le vote de tous ceux dont la voix compte
+func (g *PetitBacGame) allContestedVotedLocked() bool {
+	for catID, byUser := range g.prejudged {
+		for userID, userAnswers := range g.answers {
+			if _, ok := byUser[userID]; ok || userAnswers[catID] == "" {
+				continue
+			}
+			votes := g.votes[catID][userID]
+			for _, p := range g.voters {
+				if g.settings.VoteThreshold == PetitBacVoteAdmin && !p.IsAdmin {
+					continue
+				}
+				if p.UserID == userID && !g.settings.SelfVote && g.settings.VoteThreshold != PetitBacVoteAdmin {
+					continue
+				}
+				if _, voted := votes[p.UserID]; !voted {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
 func (g *PetitBacGame) SubmitVotes(userID int, votes map[int]map[int]bool) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
@@ -145,52 +364,69 @@ func (g *PetitBacGame) SubmitVotes(userID int, votes map[int]map[int]bool) error
 			g.votes[catID][targetUserID][userID] = valid
 		}
 	}
+
+	// validation par dictionnaire : inutile d'attendre la fin du chrono quand tout est voté
+	if g.settings.DictionaryCheck && g.allContestedVotedLocked() {
+		if g.timer != nil {
+			g.timer.Stop()
+			g.timer = nil
+		}
+		go g.onValidationEnd()
+	}
 	return nil
 }
 
 func (g *PetitBacGame) onValidationEnd() {
 	g.mu.Lock()
 	defer g.mu.Unlock()
+	if g.phase != "validation" {
+		return
+	}
 	ctx := context.Background()
-	nbPlayers := countPlayersInRoom(g.roomID)
+	nbPlayers, adminID := countPlayersInRoom(g.roomID)
 	categories, _ := ListPetitBacCategories(ctx, g.roomID)
+	roundPoints := map[int]int{}
+	validated := map[int]map[int]bool{} // userID -> catID -> réponse validée
 
 	for _, cat := range categories {
 		catID := cat.ID
 		for userID, userAnswers := range g.answers {
 			answer := userAnswers[catID]
-			// Compter les votes valides
-			nbVotes := 0
-			if g.votes[catID] != nil && g.votes[catID][userID] != nil {
-				for _, valid := range g.votes[catID][userID] {
-					if valid {
-						nbVotes++
-					}
-				}
+			var votes map[int]bool
+			if g.votes[catID] != nil {
+				votes = g.votes[catID][userID]
+			}
+			// réponse jugée d'office par le dictionnaire, sinon verdict des votes
+			isValid, prejudged := g.prejudged[catID][userID]
+			if !prejudged {
+				isValid = answer != "" && strings.HasPrefix(strings.ToUpper(answer), g.letter) &&
+					g.settings.accepted(votes, userID, nbPlayers, adminID)
 			}
-			seuil := (2*nbPlayers + 2) / 3
-			isValid := nbVotes >= seuil && answer != "" && strings.HasPrefix(strings.ToUpper(answer), g.letter)
 			// Compter combien de joueurs ont donné cette réponse
 			count := 0
+			key := g.settings.answerKey(answer)
 			for _, ansMap := range g.answers {
-				if ansMap[catID] == answer && answer != "" {
+				if ansMap[catID] != "" && g.settings.answerKey(ansMap[catID]) == key {
 					count++
 				}
 			}
-			points := 0
-			if isValid {
-				if count == 1 {
-					points = 2
-				} else {
-					points = 1
-				}
+			points := g.settings.points(answer, isValid, count)
+			if points != 0 {
+				_ = AddScore(ctx, g.roomID, userID, points)
+			}
+			roundPoints[userID] += points
+			if validated[userID] == nil {
+				validated[userID] = map[int]bool{}
 			}
-			_ = AddScore(ctx, g.roomID, userID, points)
+			validated[userID][catID] = isValid
 		}
 	}
+	g.recordRoundLocked(categories, roundPoints, validated)
 
 	if g.round >= g.totalRounds {
 		g.phase = "finished"
+		g.ratings = finishGameRecord(g.gameID)
+		markRoomFinished(g.roomID)
 		BroadcastRoomUpdated(g.roomID)
 		return
 	}
@@ -198,7 +434,8 @@ func (g *PetitBacGame) onValidationEnd() {
 	// Manche suivante
 	g.round++
 	g.phase = "playing"
-	g.letter = randomLetter()
+	g.letter = g.letters.next()
+	g.stopBy = 0
 	g.answers = map[int]map[int]string{}
 	g.votes = map[int]map[int]map[int]bool{}
 	g.endsAt = time.Now().Add(g.timePerRound)
@@ -208,6 +445,48 @@ func (g *PetitBacGame) onValidationEnd() {
 	BroadcastRoomUpdated(g.roomID)
 }
 
+// PetitBacRecordedAnswer est la réponse d'un joueur dans une catégorie, telle qu'enregistrée dans l'historique
+type PetitBacRecordedAnswer struct {
+	Answer string `json:"answer"`
+	Valid  bool   `json:"valid"`
+}
+
+// recordRoundLocked enregistre la manche : réponses par catégorie, votes reçus et points de chaque joueur
+func (g *PetitBacGame) recordRoundLocked(categories []PetitBacCategory, points map[int]int, validated map[int]map[int]bool) {
+	if g.gameID == 0 {
+		return
+	}
+	names := make(map[int]string, len(categories))
+	for _, cat := range categories {
+		names[cat.ID] = cat.Name
+	}
+
+	results := make([]RoundResult, 0, len(g.answers))
+	for userID, userAnswers := range g.answers {
+		answers := map[string]PetitBacRecordedAnswer{}
+		votes := map[string]map[int]bool{} // catégorie -> votant -> valide
+		for catID, name := range names {
+			answers[name] = PetitBacRecordedAnswer{
+				Answer: userAnswers[catID],
+				Valid:  validated[userID][catID],
+			}
+			if g.votes[catID] != nil && g.votes[catID][userID] != nil {
+				votes[name] = g.votes[catID][userID]
+			}
+		}
+		results = append(results, RoundResult{
+			UserID: userID,
+			Answer: mustJSON(answers),
+			Votes:  mustJSON(votes),
+			Points: points[userID],
+		})
+	}
+	recordRound(g.gameID, g.round, g.letter, map[string]any{
+		"letter":     g.letter,
+		"categories": categories,
+	}, results)
+}
+
 func (g *PetitBacGame) StateForUser(userID int) map[string]any {
 	g.mu.Lock()
 	defer g.mu.Unlock()
@@ -242,10 +521,24 @@ func (g *PetitBacGame) StateForUser(userID int) map[string]any {
 		"votes":       g.votes,
 		"scores":      scores,
 		"players":     players,
+		"ratings":     g.ratings,
+		"rules":       g.settings,
+		"stoppedBy":   g.stopBy,
+		"seed":        g.seed,
+		"prejudged":   g.prejudged,
 	}
 }
 
-func countPlayersInRoom(roomID int) int {
+// countPlayersInRoom ne compte que les joueurs (les spectateurs ne votent pas) et renvoie l'admin de la salle
+func countPlayersInRoom(roomID int) (n, adminID int) {
 	players, _ := ListRoomPlayers(context.Background(), roomID)
-	return len(players)
+	for _, p := range players {
+		if p.IsAdmin {
+			adminID = p.UserID
+		}
+		if !p.IsSpectator {
+			n++
+		}
+	}
+	return n, adminID
 }
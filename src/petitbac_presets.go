@@ -0,0 +1,264 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Limites des modèles de catégories du Petit Bac
+const (
+	maxPresetNameLen       = 40
+	maxUserPetitBacPresets = 20
+
+	// modèle fourni utilisé pour les nouvelles salles
+	defaultPetitBacPreset = "Musique"
+)
+
+var (
+	ErrPresetNotFound  = errors.New("preset not found")
+	ErrInvalidPreset   = errors.New("invalid preset")
+	ErrPresetNameTaken = errors.New("preset name already taken")
+	ErrTooManyPresets  = errors.New("too many presets")
+	ErrRoomNotEditable = errors.New("room is playing")
+)
+
+// PetitBacPreset est un modèle de catégories ; c'est aussi le format d'import/export JSON
+type PetitBacPreset struct {
+	ID         int      `json:"id,omitempty"`
+	Name       string   `json:"name"`
+	BuiltIn    bool     `json:"built_in,omitempty"`
+	Categories []string `json:"categories"`
+}
+
+// Normalize nettoie le nom et les catégories puis vérifie les limites
+func (p *PetitBacPreset) Normalize() error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" || len([]rune(p.Name)) > maxPresetNameLen {
+		return fmt.Errorf("%w: name must be 1-%d characters", ErrInvalidPreset, maxPresetNameLen)
+	}
+	seen := make(map[string]bool, len(p.Categories))
+	cats := make([]string, 0, len(p.Categories))
+	for _, c := range p.Categories {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		if len([]rune(c)) > maxPetitBacCategoryName {
+			return fmt.Errorf("%w: category %q is too long", ErrInvalidPreset, c)
+		}
+		key := normalizeGuess(c)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		cats = append(cats, c)
+	}
+	if len(cats) == 0 || len(cats) > maxPetitBacCategories {
+		return fmt.Errorf("%w: 1-%d categories required", ErrInvalidPreset, maxPetitBacCategories)
+	}
+	p.Categories = cats
+	return nil
+}
+
+// ListPetitBacPresets renvoie les modèles fournis puis ceux du joueur
+func ListPetitBacPresets(ctx context.Context, userID int) ([]PetitBacPreset, error) {
+	if Rekdb == nil {
+		return nil, ErrDatabaseNotInitialised
+	}
+	rows, err := Rekdb.QueryContext(ctx, SQLListPetitBacPresets, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var presets []PetitBacPreset
+	index := map[int]int{}
+	for rows.Next() {
+		var p PetitBacPreset
+		var owner sql.NullInt64
+		if err := rows.Scan(&p.ID, &p.Name, &owner); err != nil {
+			return nil, err
+		}
+		p.BuiltIn = !owner.Valid
+		index[p.ID] = len(presets)
+		presets = append(presets, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	catRows, err := Rekdb.QueryContext(ctx, SQLListPetitBacPresetCategories, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer catRows.Close()
+	for catRows.Next() {
+		var id int
+		var name string
+		if err := catRows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		if i, ok := index[id]; ok {
+			presets[i].Categories = append(presets[i].Categories, name)
+		}
+	}
+	return presets, catRows.Err()
+}
+
+// GetPetitBacPreset charge un modèle fourni ou appartenant au joueur
+func GetPetitBacPreset(ctx context.Context, userID, id int) (*PetitBacPreset, error) {
+	if Rekdb == nil {
+		return nil, ErrDatabaseNotInitialised
+	}
+	var p PetitBacPreset
+	var owner sql.NullInt64
+	err := Rekdb.QueryRowContext(ctx, SQLSelectPetitBacPreset, id, userID).Scan(&p.ID, &p.Name, &owner)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPresetNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.BuiltIn = !owner.Valid
+
+	rows, err := Rekdb.QueryContext(ctx, SQLListPetitBacPresetCategoriesByID, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		p.Categories = append(p.Categories, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// CreatePetitBacPreset enregistre un modèle du joueur (création, import ou copie d'une salle)
+func CreatePetitBacPreset(ctx context.Context, userID int, p PetitBacPreset) (*PetitBacPreset, error) {
+	if Rekdb == nil {
+		return nil, ErrDatabaseNotInitialised
+	}
+	if err := p.Normalize(); err != nil {
+		return nil, err
+	}
+
+	tx, err := Rekdb.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRowContext(ctx, SQLCountUserPetitBacPresets, userID).Scan(&count); err != nil {
+		return nil, err
+	}
+	if count >= maxUserPetitBacPresets {
+		return nil, ErrTooManyPresets
+	}
+	var exists int
+	err = tx.QueryRowContext(ctx, SQLPetitBacPresetNameExists, userID, p.Name).Scan(&exists)
+	if err == nil {
+		return nil, ErrPresetNameTaken
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	res, err := tx.ExecContext(ctx, SQLInsertPetitBacPreset, p.Name, userID, time.Now().Unix())
+	if err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "unique") {
+			return nil, ErrPresetNameTaken
+		}
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range p.Categories {
+		if _, err := tx.ExecContext(ctx, SQLInsertPetitBacPresetCategory, id, name, i+1); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	p.ID = int(id)
+	p.BuiltIn = false
+	return &p, nil
+}
+
+// DeletePetitBacPreset supprime un modèle du joueur (les modèles fournis ne se suppriment pas)
+func DeletePetitBacPreset(ctx context.Context, userID, id int) error {
+	if Rekdb == nil {
+		return ErrDatabaseNotInitialised
+	}
+	tx, err := Rekdb.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, SQLDeletePetitBacPreset, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrPresetNotFound
+	}
+	if _, err := tx.ExecContext(ctx, SQLDeletePetitBacPresetCategories, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ApplyPetitBacPreset remplace les catégories de la salle par celles du modèle
+func ApplyPetitBacPreset(ctx context.Context, roomID, userID, presetID int) error {
+	if Rekdb == nil {
+		return ErrDatabaseNotInitialised
+	}
+	if _, err := GetPetitBacPreset(ctx, userID, presetID); err != nil {
+		return err
+	}
+
+	tx, err := Rekdb.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := ensureRoomEditableTx(ctx, tx, roomID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, SQLDeleteRoomPetitBacCategories, roomID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, SQLCopyPetitBacPresetToRoom, roomID, presetID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SaveRoomAsPetitBacPreset enregistre les catégories actuelles de la salle comme modèle du joueur
+func SaveRoomAsPetitBacPreset(ctx context.Context, roomID, userID int, name string) (*PetitBacPreset, error) {
+	cats, err := ListPetitBacCategories(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	p := PetitBacPreset{Name: name}
+	for _, c := range cats {
+		p.Categories = append(p.Categories, c.Name)
+	}
+	return CreatePetitBacPreset(ctx, userID, p)
+}
@@ -0,0 +1,73 @@
+package server
+
+import "strings"
+
+// Articles ignorés en tête de réponse quand les doublons sont comparés après normalisation
+var petitBacArticles = map[string]bool{
+	"le": true, "la": true, "les": true, "l": true,
+	"un": true, "une": true, "des": true, "du": true,
+	"the": true,
+}
+
+// petitBacWordKey normalise une réponse : sans accents, majuscules ni article en tête
+func petitBacWordKey(answer string) string {
+	words := strings.Fields(normalizeGuess(answer))
+	if len(words) > 1 && petitBacArticles[words[0]] {
+		words = words[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// answerKey renvoie la forme d'une réponse utilisée pour repérer les réponses partagées
+func (s PetitBacSettings) answerKey(answer string) string {
+	if !s.NormalizeDuplicates {
+		return answer
+	}
+	return petitBacWordKey(answer)
+}
+
+// accepted indique si les votes reçus valident la réponse de targetID.
+// players : nombre de joueurs (hors spectateurs), adminID : administrateur de la salle.
+func (s PetitBacSettings) accepted(votes map[int]bool, targetID, players, adminID int) bool {
+	if s.VoteThreshold == PetitBacVoteAdmin {
+		// l'admin tranche seul, y compris sur ses propres réponses
+		return votes[adminID]
+	}
+
+	voters, yes := players, 0
+	if !s.SelfVote {
+		voters--
+	}
+	for voterID, valid := range votes {
+		if valid && (s.SelfVote || voterID != targetID) {
+			yes++
+		}
+	}
+	// personne d'autre pour juger : la réponse passe (la lettre reste vérifiée)
+	if voters <= 0 {
+		return true
+	}
+
+	switch s.VoteThreshold {
+	case PetitBacVoteMajority:
+		return 2*yes > voters
+	case PetitBacVoteUnanimous:
+		return yes >= voters
+	default:
+		return yes >= (2*voters+2)/3
+	}
+}
+
+// points : barème d'une réponse selon sa validation et le nombre de joueurs l'ayant donnée
+func (s PetitBacSettings) points(answer string, valid bool, shared int) int {
+	switch {
+	case answer == "":
+		return 0
+	case !valid:
+		return s.PointsInvalid
+	case shared > 1:
+		return s.PointsShared
+	default:
+		return s.PointsUnique
+	}
+}
@@ -0,0 +1,499 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limites des playlists créées par les joueurs
+const (
+	maxPlaylistNameLen = 60
+	maxPlaylistTracks  = 200
+	maxUserPlaylists   = 30
+	maxAltAnswers      = 5
+	maxGameTracks      = 100 // comme pour Deezer et la bibliothèque locale
+	gameTrackMargin    = 5   // titres résolus en plus des manches, pour ceux sans extrait
+
+	// sources d'un titre de playlist
+	PlaylistTrackDeezer = "deezer"
+	PlaylistTrackLocal  = "local"
+)
+
+var (
+	ErrPlaylistNotFound  = errors.New("playlist not found")
+	ErrPlaylistForbidden = errors.New("playlist belongs to another player")
+	ErrInvalidPlaylist   = errors.New("invalid playlist")
+	ErrPlaylistNameTaken = errors.New("playlist name already taken")
+	ErrTooManyPlaylists  = errors.New("too many playlists")
+	ErrPlaylistFull      = errors.New("playlist is full")
+	ErrPlaylistEmpty     = errors.New("aucune chanson jouable dans la playlist")
+)
+
+// Playlist est une playlist de Blindtest d'un joueur, partagée ou non avec ses amis
+type Playlist struct {
+	ID          int             `json:"id"`
+	OwnerID     int             `json:"owner_id"`
+	OwnerPseudo string          `json:"owner"`
+	Name        string          `json:"name"`
+	TrackCount  int             `json:"track_count"`
+	Tracks      []PlaylistTrack `json:"tracks,omitempty"`
+	SharedWith  []string        `json:"shared_with,omitempty"` // rempli pour le propriétaire seulement
+}
+
+// PlaylistTrack est un titre de playlist ; les réponses alternatives s'ajoutent au titre et à l'artiste
+type PlaylistTrack struct {
+	ID         int      `json:"id,omitempty"`
+	Source     string   `json:"source"`
+	Ref        string   `json:"ref"` // id Deezer du titre ou chemin dans -media-dir
+	Title      string   `json:"title"`
+	Artist     string   `json:"artist"`
+	Album      string   `json:"album,omitempty"`
+	AltTitles  []string `json:"alt_titles,omitempty"`
+	AltArtists []string `json:"alt_artists,omitempty"`
+}
+
+// splitAltAnswers lit une liste « a ; b ; c » (formulaire et base)
+func splitAltAnswers(s string) []string {
+	var out []string
+	for _, a := range strings.Split(s, ";") {
+		if a = strings.TrimSpace(a); a != "" {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// Normalize nettoie un titre et vérifie sa source
+func (t *PlaylistTrack) Normalize() error {
+	t.Source = strings.ToLower(strings.TrimSpace(t.Source))
+	t.Ref = strings.TrimSpace(t.Ref)
+	t.Title = strings.TrimSpace(t.Title)
+	t.Artist = strings.TrimSpace(t.Artist)
+	t.Album = strings.TrimSpace(t.Album)
+	switch t.Source {
+	case PlaylistTrackDeezer:
+		if _, err := strconv.ParseInt(t.Ref, 10, 64); err != nil {
+			return fmt.Errorf("%w: deezer track id %q", ErrInvalidPlaylist, t.Ref)
+		}
+	case PlaylistTrackLocal:
+		t.Ref = cleanManifestPath(t.Ref)
+		if t.Ref == "" {
+			return fmt.Errorf("%w: empty local file", ErrInvalidPlaylist)
+		}
+	default:
+		return fmt.Errorf("%w: unknown source %q", ErrInvalidPlaylist, t.Source)
+	}
+	if t.Title == "" {
+		return fmt.Errorf("%w: track title required", ErrInvalidPlaylist)
+	}
+	t.AltTitles = splitAltAnswers(strings.Join(t.AltTitles, ";"))
+	t.AltArtists = splitAltAnswers(strings.Join(t.AltArtists, ";"))
+	if len(t.AltTitles) > maxAltAnswers || len(t.AltArtists) > maxAltAnswers {
+		return fmt.Errorf("%w: at most %d alternate answers", ErrInvalidPlaylist, maxAltAnswers)
+	}
+	return nil
+}
+
+func normalizePlaylistName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len([]rune(name)) > maxPlaylistNameLen {
+		return "", fmt.Errorf("%w: name must be 1-%d characters", ErrInvalidPlaylist, maxPlaylistNameLen)
+	}
+	return name, nil
+}
+
+// ListPlaylists renvoie les playlists du joueur puis celles que ses amis lui ont partagées
+func ListPlaylists(ctx context.Context, userID int) ([]Playlist, error) {
+	if Rekdb == nil {
+		return nil, ErrDatabaseNotInitialised
+	}
+	rows, err := Rekdb.QueryContext(ctx, SQLListUserPlaylists, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Playlist
+	for rows.Next() {
+		var p Playlist
+		if err := rows.Scan(&p.ID, &p.OwnerID, &p.OwnerPseudo, &p.Name, &p.TrackCount); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// GetPlaylist charge une playlist visible par le joueur (la sienne ou partagée), avec ses titres
+func GetPlaylist(ctx context.Context, userID, id int) (*Playlist, error) {
+	if Rekdb == nil {
+		return nil, ErrDatabaseNotInitialised
+	}
+	var p Playlist
+	err := Rekdb.QueryRowContext(ctx, SQLSelectPlaylistForUser, id, userID).Scan(&p.ID, &p.OwnerID, &p.OwnerPseudo, &p.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPlaylistNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p.Tracks, err = listPlaylistTracks(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	p.TrackCount = len(p.Tracks)
+
+	if p.OwnerID == userID {
+		rows, err := Rekdb.QueryContext(ctx, SQLListPlaylistShares, p.ID)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var pseudo string
+			if err := rows.Scan(&pseudo); err != nil {
+				return nil, err
+			}
+			p.SharedWith = append(p.SharedWith, pseudo)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return &p, nil
+}
+
+func listPlaylistTracks(ctx context.Context, playlistID int) ([]PlaylistTrack, error) {
+	rows, err := Rekdb.QueryContext(ctx, SQLListPlaylistTracks, playlistID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []PlaylistTrack
+	for rows.Next() {
+		var t PlaylistTrack
+		var altTitles, altArtists string
+		if err := rows.Scan(&t.ID, &t.Source, &t.Ref, &t.Title, &t.Artist, &t.Album, &altTitles, &altArtists); err != nil {
+			return nil, err
+		}
+		t.AltTitles = splitAltAnswers(altTitles)
+		t.AltArtists = splitAltAnswers(altArtists)
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// ownPlaylist vérifie que la playlist existe et appartient au joueur
+func ownPlaylist(ctx context.Context, userID, id int) (*Playlist, error) {
+	p, err := GetPlaylist(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if p.OwnerID != userID {
+		return nil, ErrPlaylistForbidden
+	}
+	return p, nil
+}
+
+// CreatePlaylist crée une playlist vide (ou avec des titres, pour l'API)
+func CreatePlaylist(ctx context.Context, userID int, name string, tracks []PlaylistTrack) (*Playlist, error) {
+	if Rekdb == nil {
+		return nil, ErrDatabaseNotInitialised
+	}
+	name, err := normalizePlaylistName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := Rekdb.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRowContext(ctx, SQLCountUserPlaylists, userID).Scan(&count); err != nil {
+		return nil, err
+	}
+	if count >= maxUserPlaylists {
+		return nil, ErrTooManyPlaylists
+	}
+	if err := checkPlaylistName(ctx, tx, userID, 0, name); err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	res, err := tx.ExecContext(ctx, SQLInsertPlaylist, userID, name, now, now)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	if err := insertPlaylistTracks(ctx, tx, int(id), tracks); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return GetPlaylist(ctx, userID, int(id))
+}
+
+// UpdatePlaylist renomme la playlist et remplace toute la liste de titres dans une transaction
+func UpdatePlaylist(ctx context.Context, userID, id int, name string, tracks []PlaylistTrack) (*Playlist, error) {
+	if Rekdb == nil {
+		return nil, ErrDatabaseNotInitialised
+	}
+	name, err := normalizePlaylistName(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ownPlaylist(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	tx, err := Rekdb.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := checkPlaylistName(ctx, tx, userID, id, name); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, SQLRenamePlaylist, name, time.Now().Unix(), id, userID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, SQLDeletePlaylistTracks, id); err != nil {
+		return nil, err
+	}
+	if err := insertPlaylistTracks(ctx, tx, id, tracks); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return GetPlaylist(ctx, userID, id)
+}
+
+func checkPlaylistName(ctx context.Context, tx *sql.Tx, userID, id int, name string) error {
+	var exists int
+	err := tx.QueryRowContext(ctx, SQLPlaylistNameExists, userID, name, id).Scan(&exists)
+	if err == nil {
+		return ErrPlaylistNameTaken
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	return nil
+}
+
+func insertPlaylistTracks(ctx context.Context, tx *sql.Tx, playlistID int, tracks []PlaylistTrack) error {
+	if len(tracks) > maxPlaylistTracks {
+		return ErrPlaylistFull
+	}
+	for i := range tracks {
+		t := tracks[i]
+		if err := t.Normalize(); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, SQLInsertPlaylistTrack, playlistID, i+1, t.Source, t.Ref, t.Title, t.Artist, t.Album,
+			strings.Join(t.AltTitles, " ; "), strings.Join(t.AltArtists, " ; ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddPlaylistTrack ajoute un titre en fin de playlist
+func AddPlaylistTrack(ctx context.Context, userID, id int, t PlaylistTrack) error {
+	if Rekdb == nil {
+		return ErrDatabaseNotInitialised
+	}
+	if err := t.Normalize(); err != nil {
+		return err
+	}
+	if _, err := ownPlaylist(ctx, userID, id); err != nil {
+		return err
+	}
+
+	tx, err := Rekdb.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var count, last int
+	if err := tx.QueryRowContext(ctx, SQLCountPlaylistTracks, id).Scan(&count); err != nil {
+		return err
+	}
+	if count >= maxPlaylistTracks {
+		return ErrPlaylistFull
+	}
+	if err := tx.QueryRowContext(ctx, SQLMaxPlaylistTrackPosition, id).Scan(&last); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, SQLInsertPlaylistTrack, id, last+1, t.Source, t.Ref, t.Title, t.Artist, t.Album,
+		strings.Join(t.AltTitles, " ; "), strings.Join(t.AltArtists, " ; ")); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, SQLTouchPlaylist, time.Now().Unix(), id, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemovePlaylistTrack retire un titre de la playlist
+func RemovePlaylistTrack(ctx context.Context, userID, id, trackID int) error {
+	if Rekdb == nil {
+		return ErrDatabaseNotInitialised
+	}
+	if _, err := ownPlaylist(ctx, userID, id); err != nil {
+		return err
+	}
+	res, err := Rekdb.ExecContext(ctx, SQLDeletePlaylistTrack, trackID, id)
+	if err != nil {
+		return err
+	}
+	// titre d'une autre playlist ou déjà supprimé
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: track %d", ErrPlaylistNotFound, trackID)
+	}
+	return nil
+}
+
+// DeletePlaylist supprime la playlist, ses titres et ses partages
+func DeletePlaylist(ctx context.Context, userID, id int) error {
+	if Rekdb == nil {
+		return ErrDatabaseNotInitialised
+	}
+	if _, err := ownPlaylist(ctx, userID, id); err != nil {
+		return err
+	}
+
+	tx, err := Rekdb.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, q := range []string{SQLDeletePlaylistTracks, SQLDeletePlaylistShares} {
+		if _, err := tx.ExecContext(ctx, q, id); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, SQLDeletePlaylist, id, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SharePlaylist partage (ou retire le partage) de la playlist avec un joueur, par pseudo
+func SharePlaylist(ctx context.Context, userID, id int, pseudo string, share bool) error {
+	if Rekdb == nil {
+		return ErrDatabaseNotInitialised
+	}
+	if _, err := ownPlaylist(ctx, userID, id); err != nil {
+		return err
+	}
+	var friendID int
+	var friendPseudo string
+	err := Rekdb.QueryRowContext(ctx, SQLSelectUserByPseudo, strings.TrimSpace(pseudo)).Scan(&friendID, &friendPseudo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if friendID == userID {
+		return fmt.Errorf("%w: cannot share with yourself", ErrInvalidPlaylist)
+	}
+	if share {
+		_, err = Rekdb.ExecContext(ctx, SQLInsertPlaylistShare, id, friendID)
+	} else {
+		_, err = Rekdb.ExecContext(ctx, SQLDeletePlaylistShare, id, friendID)
+	}
+	return err
+}
+
+// PlaylistTracksForGame convertit les titres de la playlist en pistes jouables :
+// extrait Deezer récupéré au lancement (les liens expirent), fichier local servi sous /media/.
+// Seuls rounds titres (plus une marge) sont tirés, pour ne pas appeler Deezer pour toute la playlist.
+func PlaylistTracksForGame(ctx context.Context, playlistID, rounds int) ([]BlindtestTrack, error) {
+	if Rekdb == nil {
+		return nil, ErrDatabaseNotInitialised
+	}
+	tracks, err := listPlaylistTracks(ctx, playlistID)
+	if err != nil {
+		return nil, err
+	}
+	lib := GetLocalLibrary()
+
+	need := maxGameTracks
+	if rounds > 0 {
+		need = min(rounds+gameTrackMargin, maxGameTracks)
+	}
+	rand.Shuffle(len(tracks), func(i, j int) {
+		tracks[i], tracks[j] = tracks[j], tracks[i]
+	})
+
+	var out []BlindtestTrack
+	var deezerIDs []int64
+	byDeezerID := map[int64]PlaylistTrack{}
+	for _, t := range tracks {
+		if len(out)+len(deezerIDs) >= need {
+			break
+		}
+		switch t.Source {
+		case PlaylistTrackLocal:
+			if lib == nil {
+				continue
+			}
+			if info, err := os.Stat(filepath.Join(lib.Dir, filepath.FromSlash(t.Ref))); err != nil || info.IsDir() {
+				continue
+			}
+			out = append(out, t.blindtestTrack(localTrackID(t.Ref), mediaRoutePrefix+escapeMediaPath(t.Ref)))
+		case PlaylistTrackDeezer:
+			id, _ := strconv.ParseInt(t.Ref, 10, 64)
+			if _, dup := byDeezerID[id]; dup {
+				continue
+			}
+			deezerIDs = append(deezerIDs, id)
+			byDeezerID[id] = t
+		}
+	}
+
+	// un appel Deezer par titre tiré qui n'a pas d'extrait valide en cache
+	previews := cachedDeezerPreviews(ctx, deezerIDs)
+	for _, id := range deezerIDs {
+		if p := previews[id]; p != "" {
+			out = append(out, byDeezerID[id].blindtestTrack(id, p))
+		}
+	}
+	if len(out) == 0 {
+		return nil, ErrPlaylistEmpty
+	}
+	return out, nil
+}
+
+func (t PlaylistTrack) blindtestTrack(id int64, preview string) BlindtestTrack {
+	return BlindtestTrack{
+		TrackID:    id,
+		PreviewURL: preview,
+		Title:      t.Title,
+		Artist:     t.Artist,
+		Album:      t.Album,
+		AltTitles:  t.AltTitles,
+		AltArtists: t.AltArtists,
+	}
+}
@@ -0,0 +1,232 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+)
+
+const profileRecentGames = 10
+
+// PlayerProfile regroupe les statistiques globales d'un joueur, calculées depuis l'historique des parties
+type PlayerProfile struct {
+	UserID      int    `json:"user_id"`
+	Pseudo      string `json:"pseudo"`
+	GamesPlayed int    `json:"games_played"`
+	Wins        int    `json:"wins"`
+	TotalPoints int    `json:"total_points"`
+
+	ByType  []GameTypeStats `json:"by_type"`
+	Ratings []PlayerRating  `json:"ratings"`
+
+	AvgBlindtestAnswerMs   int64   `json:"avg_blindtest_answer_ms"`  // temps moyen pour trouver (0 si jamais trouvé)
+	BlindtestFound         int     `json:"blindtest_found"`          // manches où le joueur a trouvé quelque chose
+	PetitBacValidationRate float64 `json:"petitbac_validation_rate"` // réponses validées / réponses données (0..1)
+	PetitBacAnswers        int     `json:"petitbac_answers"`
+
+	BestWinStreak       int `json:"best_win_streak"`
+	CurrentWinStreak    int `json:"current_win_streak"`
+	BestBlindtestStreak int `json:"best_blindtest_streak"` // manches de Blind Test trouvées d'affilée
+
+	FavouriteGenre string `json:"favourite_genre,omitempty"`
+
+	RecentGames []ProfileGame `json:"recent_games"`
+}
+
+type GameTypeStats struct {
+	Type   RoomType `json:"type"`
+	Label  string   `json:"label"`
+	Played int      `json:"played"`
+	Wins   int      `json:"wins"`
+	Points int      `json:"points"`
+}
+
+// ProfileGame : une partie terminée vue par le joueur
+type ProfileGame struct {
+	GameID  int64    `json:"game_id"`
+	Type    RoomType `json:"type"`
+	Label   string   `json:"label"`
+	EndedAt int64    `json:"ended_at"`
+	Score   int      `json:"score"`
+	Rank    int      `json:"rank"`
+	Players int      `json:"players"`
+	Won     bool     `json:"won"`
+}
+
+// isWin : premier (ex aequo compris) d'une partie où il a marqué des points
+func isWin(rank, score int) bool {
+	return rank == 1 && score > 0
+}
+
+// GetPlayerProfile calcule le profil d'un joueur à partir de son pseudo
+func GetPlayerProfile(ctx context.Context, pseudo string) (*PlayerProfile, error) {
+	if Rekdb == nil {
+		return nil, ErrDatabaseNotInitialised
+	}
+
+	p := &PlayerProfile{ByType: []GameTypeStats{}, RecentGames: []ProfileGame{}}
+	err := Rekdb.QueryRowContext(ctx, SQLSelectUserByPseudo, pseudo).Scan(&p.UserID, &p.Pseudo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.loadGames(ctx); err != nil {
+		return nil, err
+	}
+	if err := p.loadRounds(ctx); err != nil {
+		return nil, err
+	}
+	if p.Ratings, err = ListUserRatings(ctx, p.UserID); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// loadGames : parties jouées, victoires, séries et genre préféré
+func (p *PlayerProfile) loadGames(ctx context.Context) error {
+	rows, err := Rekdb.QueryContext(ctx, SQLListUserFinishedGames, p.UserID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	byType := map[RoomType]*GameTypeStats{}
+	genres := map[string]int{}
+	var games []ProfileGame
+	streak := 0
+
+	for rows.Next() {
+		var g ProfileGame
+		var genre string
+		if err := rows.Scan(&g.GameID, &g.Type, &g.EndedAt, &genre, &g.Score, &g.Rank, &g.Players); err != nil {
+			return err
+		}
+		g.Label = g.Type.Label()
+		g.Won = isWin(g.Rank, g.Score)
+		games = append(games, g)
+
+		st := byType[g.Type]
+		if st == nil {
+			st = &GameTypeStats{Type: g.Type, Label: g.Label}
+			byType[g.Type] = st
+		}
+		st.Played++
+		st.Points += g.Score
+		p.GamesPlayed++
+		p.TotalPoints += g.Score
+
+		if g.Won {
+			st.Wins++
+			p.Wins++
+			streak++
+			p.BestWinStreak = max(p.BestWinStreak, streak)
+		} else {
+			streak = 0
+		}
+
+		// même champ que le classement par genre (vide pour une playlist, un album, un artiste…)
+		if genre != "" {
+			genres[genre]++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	p.CurrentWinStreak = streak
+
+	for _, t := range []RoomType{RoomTypeBlindTest, RoomTypePetitBac} {
+		if st, ok := byType[t]; ok {
+			p.ByType = append(p.ByType, *st)
+		}
+	}
+
+	best := 0
+	for genre, n := range genres {
+		if n > best || (n == best && genre < p.FavouriteGenre) {
+			best = n
+			p.FavouriteGenre = genre
+		}
+	}
+
+	// les plus récentes d'abord
+	for i := len(games) - 1; i >= 0 && len(p.RecentGames) < profileRecentGames; i-- {
+		p.RecentGames = append(p.RecentGames, games[i])
+	}
+	return nil
+}
+
+// loadRounds : temps de réponse au Blind Test et taux de validation au Petit Bac
+func (p *PlayerProfile) loadRounds(ctx context.Context) error {
+	rows, err := Rekdb.QueryContext(ctx, SQLListUserRoundResults, p.UserID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var totalMs int64
+	foundStreak := 0
+	validated := 0
+
+	for rows.Next() {
+		var typ RoomType
+		var answer string
+		if err := rows.Scan(&typ, &answer); err != nil {
+			return err
+		}
+
+		switch typ {
+		case RoomTypeBlindTest:
+			var attempts []BlindtestAttempt
+			if json.Unmarshal([]byte(answer), &attempts) != nil {
+				continue
+			}
+			// un seul temps par manche : celui de la première trouvaille
+			firstFind := true
+			titleFound := false
+			for _, a := range attempts {
+				if a.Matched.Any() && firstFind {
+					firstFind = false
+					p.BlindtestFound++
+					totalMs += a.Elapsed
+				}
+				titleFound = titleFound || a.Matched.Title
+			}
+			if titleFound {
+				foundStreak++
+				p.BestBlindtestStreak = max(p.BestBlindtestStreak, foundStreak)
+			} else {
+				foundStreak = 0
+			}
+
+		case RoomTypePetitBac:
+			var answers map[string]PetitBacRecordedAnswer
+			if json.Unmarshal([]byte(answer), &answers) != nil {
+				continue
+			}
+			for _, a := range answers {
+				if a.Answer == "" {
+					continue
+				}
+				p.PetitBacAnswers++
+				if a.Valid {
+					validated++
+				}
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if p.BlindtestFound > 0 {
+		p.AvgBlindtestAnswerMs = totalMs / int64(p.BlindtestFound)
+	}
+	if p.PetitBacAnswers > 0 {
+		p.PetitBacValidationRate = float64(validated) / float64(p.PetitBacAnswers)
+	}
+	return nil
+}
@@ -1,54 +1,6 @@
 package server
 
-// TablesSQL contient toutes les requêtes de création de tables
-var TablesSQL = map[string]string{
-	"users": `CREATE TABLE IF NOT EXISTS users (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		pseudo TEXT UNIQUE NOT NULL,
-		email TEXT UNIQUE NOT NULL,
-		password_hash TEXT NOT NULL
-	);`,
-	"rooms": `CREATE TABLE IF NOT EXISTS rooms (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		code TEXT UNIQUE NOT NULL,
-		type TEXT NOT NULL,
-		creator_id INTEGER NOT NULL,
-		max_players INTEGER NOT NULL,
-		time_per_round INTEGER NOT NULL,
-		rounds INTEGER NOT NULL,
-		status TEXT NOT NULL
-	);`,
-	"room_players": `CREATE TABLE IF NOT EXISTS room_players (
-		room_id INTEGER NOT NULL,
-		user_id INTEGER NOT NULL,
-		is_admin INTEGER DEFAULT 0,
-		is_ready INTEGER DEFAULT 0,
-		score INTEGER DEFAULT 0,
-		PRIMARY KEY (room_id, user_id)
-	);`,
-	"room_blindtest_settings": `CREATE TABLE IF NOT EXISTS room_blindtest_settings (
-    room_id INTEGER PRIMARY KEY,
-    playlist TEXT NOT NULL
-);`,
-	"room_petitbac_categories": `CREATE TABLE IF NOT EXISTS room_petitbac_categories (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    room_id INTEGER NOT NULL,
-    name TEXT NOT NULL,
-    position INTEGER NOT NULL
-);`,
-}
-
 // Fonction pour inserer les données d'un nouvel utilisateur dans la base de données
-//variable IndexesSQL contient les requêtes de création d'index LIANT les tables entre elles
-
-var IndexesSQL = map[string]string{
-	"idx_rooms_owner":                  "CREATE INDEX IF NOT EXISTS idx_rooms_owner ON rooms(creator_id);",
-	"idx_room_players_room":            "CREATE INDEX IF NOT EXISTS idx_room_players_room ON room_players(room_id);",
-	"idx_blindtest_settings_room":      "CREATE INDEX IF NOT EXISTS idx_blindtest_settings_room ON room_blindtest_settings(room_id);",
-	"idx_petitbac_categories_room":     "CREATE INDEX IF NOT EXISTS idx_petitbac_categories_room ON room_petitbac_categories(room_id);",
-	"idx_petitbac_categories_room_pos": "CREATE UNIQUE INDEX IF NOT EXISTS idx_petitbac_categories_room_pos ON room_petitbac_categories(room_id, position);",
-}
-
 func InsertValuesUser(pseudo, email, passwordHash string) error {
 	_, err := Rekdb.Exec("INSERT INTO users (pseudo, email, password_hash) VALUES (?, ?, ?)", pseudo, email, passwordHash)
 	return err
@@ -57,28 +9,41 @@ func InsertValuesUser(pseudo, email, passwordHash string) error {
 // PRAGMA
 const SQLPragmaForeignKeysOn = `PRAGMA foreign_keys = ON`
 
+// Migrations (le schéma lui-même est dans src/migrations/*.sql)
+const (
+	SQLCreateSchemaMigrations = `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at INTEGER NOT NULL
+	);`
+	SQLListSchemaMigrations  = `SELECT version, applied_at FROM schema_migrations ORDER BY version ASC`
+	SQLInsertSchemaMigration = `INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`
+)
+
 // Rooms / Players / Users queries
 const (
 	SQLUserExistsByID = `SELECT 1 FROM users WHERE id = ? LIMIT 1`
 
 	SQLInsertRoomLobby = `
         INSERT INTO rooms (code, type, creator_id, max_players, time_per_round, rounds, status)
-        VALUES (?, ?, ?, ?, ?, ?, 'lobby')
+        VALUES (?, ?, ?, ?, ?, ?, ?)
     `
 
 	SQLInsertRoomPlayerAdmin = `
-        INSERT INTO room_players (room_id, user_id, is_admin, is_ready, score)
-        VALUES (?, ?, 1, 0, 0)
+        INSERT INTO room_players (room_id, user_id, is_admin, is_ready, score, joined_at)
+        VALUES (?, ?, 1, 0, 0, ?)
     `
 
 	SQLSelectRoomByID = `
-        SELECT id, code, type, creator_id, max_players, time_per_round, rounds, status
+        SELECT id, code, type, creator_id, max_players, time_per_round, rounds, status,
+               require_ready, ready_quorum, auto_start
         FROM rooms
         WHERE id = ?
     `
 
 	SQLSelectRoomByCode = `
-        SELECT id, code, type, creator_id, max_players, time_per_round, rounds, status
+        SELECT id, code, type, creator_id, max_players, time_per_round, rounds, status,
+               require_ready, ready_quorum, auto_start
         FROM rooms
         WHERE code = ?
     `
@@ -90,12 +55,12 @@ const (
 	SQLSelectUserPseudoByID = `SELECT pseudo FROM users WHERE id = ?`
 
 	SQLInsertRoomPlayerMember = `
-        INSERT INTO room_players (room_id, user_id, is_admin, is_ready, score)
-        VALUES (?, ?, ?, 0, 0)
+        INSERT INTO room_players (room_id, user_id, is_admin, is_ready, score, is_spectator, joined_at)
+        VALUES (?, ?, ?, 0, 0, ?, ?)
     `
 
 	SQLListRoomPlayersByRoomID = `
-        SELECT u.id, u.pseudo, rp.is_admin, rp.is_ready, rp.score
+        SELECT u.id, u.pseudo, rp.is_admin, rp.is_ready, rp.score, rp.is_spectator
         FROM room_players rp
         JOIN users u ON u.id = rp.user_id
         WHERE rp.room_id = ?
@@ -106,22 +71,312 @@ const (
         SELECT is_admin FROM room_players WHERE room_id = ? AND user_id = ?
     `
 
+	SQLSelectIsSpectatorInRoom = `SELECT is_spectator FROM room_players WHERE room_id = ? AND user_id = ?`
+
+	// Cycle de vie de la salle
+	SQLSelectRoomStatus      = `SELECT status FROM rooms WHERE id = ?`
+	SQLUpdateRoomStatus      = `UPDATE rooms SET status = ? WHERE id = ? AND status = ?`
+	SQLPromoteRoomSpectators = `UPDATE room_players SET is_spectator = 0 WHERE room_id = ?`
+
+	// Succession de l'admin : le joueur présent depuis le plus longtemps
+	SQLSelectOldestRoomPlayer = `
+        SELECT user_id FROM room_players
+        WHERE room_id = ?
+        ORDER BY joined_at ASC, rowid ASC
+        LIMIT 1
+    `
+	SQLSetRoomPlayerAdmin = `UPDATE room_players SET is_admin = ? WHERE room_id = ? AND user_id = ?`
+
+	// Bannissements
+	SQLRoomBanExists = `SELECT 1 FROM room_bans WHERE room_id = ? AND user_id = ?`
+	SQLInsertRoomBan = `INSERT OR IGNORE INTO room_bans (room_id, user_id, banned_by, banned_at) VALUES (?, ?, ?, ?)`
+
 	// Blindtest settings
-	SQLSelectBlindtestPlaylistByRoomID = `SELECT playlist FROM room_blindtest_settings WHERE room_id = ?`
-	SQLUpsertBlindtestPlaylist         = `
-    INSERT INTO room_blindtest_settings (room_id, playlist)
-    VALUES (?, ?)
-    ON CONFLICT(room_id) DO UPDATE SET playlist = excluded.playlist
+	SQLSelectBlindtestSettingsByRoomID = `
+    SELECT playlist, source_kind, source_id, max_attempts, first_bonus, streak_bonus_pct, min_points, wrong_penalty
+    FROM room_blindtest_settings WHERE room_id = ?
+`
+	SQLUpsertBlindtestSettings = `
+    INSERT INTO room_blindtest_settings (room_id, playlist, source_kind, source_id, max_attempts, first_bonus, streak_bonus_pct, min_points, wrong_penalty)
+    VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+    ON CONFLICT(room_id) DO UPDATE SET
+        playlist = excluded.playlist,
+        source_kind = excluded.source_kind,
+        source_id = excluded.source_id,
+        max_attempts = excluded.max_attempts,
+        first_bonus = excluded.first_bonus,
+        streak_bonus_pct = excluded.streak_bonus_pct,
+        min_points = excluded.min_points,
+        wrong_penalty = excluded.wrong_penalty
+`
+
+	// Petit bac settings
+	SQLSelectPetitBacSettingsByRoomID = `
+    SELECT points_unique, points_shared, points_invalid, vote_threshold, self_vote, normalize_duplicates,
+        end_mode, stop_grace_seconds, excluded_letters, hard_letters, letter_pool, letter_seed, dictionary_check
+    FROM room_petitbac_settings WHERE room_id = ?
+`
+	SQLUpsertPetitBacSettings = `
+    INSERT INTO room_petitbac_settings (room_id, points_unique, points_shared, points_invalid, vote_threshold, self_vote, normalize_duplicates,
+        end_mode, stop_grace_seconds, excluded_letters, hard_letters, letter_pool, letter_seed, dictionary_check)
+    VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    ON CONFLICT(room_id) DO UPDATE SET
+        points_unique = excluded.points_unique,
+        points_shared = excluded.points_shared,
+        points_invalid = excluded.points_invalid,
+        vote_threshold = excluded.vote_threshold,
+        self_vote = excluded.self_vote,
+        normalize_duplicates = excluded.normalize_duplicates,
+        end_mode = excluded.end_mode,
+        stop_grace_seconds = excluded.stop_grace_seconds,
+        excluded_letters = excluded.excluded_letters,
+        hard_letters = excluded.hard_letters,
+        letter_pool = excluded.letter_pool,
+        letter_seed = excluded.letter_seed,
+        dictionary_check = excluded.dictionary_check
 `
 
 	// Petit bac categories
 	SQLCountPetitBacCategoriesByRoomID = `SELECT COUNT(*) FROM room_petitbac_categories WHERE room_id = ?`
 	SQLListPetitBacCategoriesByRoomID  = `SELECT id, name, position FROM room_petitbac_categories WHERE room_id = ? ORDER BY position ASC`
-	
+
 	SQLInsertPetitBacCategory = `INSERT INTO room_petitbac_categories (room_id, name, position) VALUES (?, ?, ?)`
-	SQLUpdatePetitBacCategory = `UPDATE room_petitbac_categories SET name = ? WHERE id = ? AND room_id = ?`
 	SQLDeletePetitBacCategory = `DELETE FROM room_petitbac_categories WHERE id = ? AND room_id = ?`
 
+	// réordonnancement : positions passées en négatif le temps de renuméroter (index unique room_id, position)
+	SQLNegatePetitBacCategoryPositions = `UPDATE room_petitbac_categories SET position = -position WHERE room_id = ?`
+	SQLMovePetitBacCategory            = `UPDATE room_petitbac_categories SET name = ?, position = ? WHERE id = ? AND room_id = ?`
+
+	SQLDeleteRoomPetitBacCategories = `DELETE FROM room_petitbac_categories WHERE room_id = ?`
+
+	// Modèles de catégories du Petit Bac (owner_id NULL = modèle fourni)
+	SQLListPetitBacPresets = `
+    SELECT id, name, owner_id FROM petitbac_presets
+    WHERE owner_id IS NULL OR owner_id = ?
+    ORDER BY owner_id IS NOT NULL, name
+`
+	SQLListPetitBacPresetCategories = `
+    SELECT c.preset_id, c.name
+    FROM petitbac_preset_categories c
+    JOIN petitbac_presets p ON p.id = c.preset_id
+    WHERE p.owner_id IS NULL OR p.owner_id = ?
+    ORDER BY c.preset_id, c.position
+`
+	SQLListPetitBacPresetCategoriesByID = `SELECT name FROM petitbac_preset_categories WHERE preset_id = ? ORDER BY position`
+	SQLSelectPetitBacPreset             = `SELECT id, name, owner_id FROM petitbac_presets WHERE id = ? AND (owner_id IS NULL OR owner_id = ?)`
+	SQLSelectBuiltinPetitBacPreset      = `SELECT id FROM petitbac_presets WHERE owner_id IS NULL AND name = ?`
+	SQLPetitBacPresetNameExists         = `SELECT 1 FROM petitbac_presets WHERE owner_id = ? AND name = ?`
+	SQLCountUserPetitBacPresets         = `SELECT COUNT(*) FROM petitbac_presets WHERE owner_id = ?`
+	SQLInsertPetitBacPreset             = `INSERT INTO petitbac_presets (name, owner_id, created_at) VALUES (?, ?, ?)`
+	SQLInsertPetitBacPresetCategory     = `INSERT INTO petitbac_preset_categories (preset_id, name, position) VALUES (?, ?, ?)`
+	SQLDeletePetitBacPreset             = `DELETE FROM petitbac_presets WHERE id = ? AND owner_id = ?`
+	SQLDeletePetitBacPresetCategories   = `DELETE FROM petitbac_preset_categories WHERE preset_id = ?`
+	SQLCopyPetitBacPresetToRoom         = `
+    INSERT INTO room_petitbac_categories (room_id, name, position)
+    SELECT ?, name, position FROM petitbac_preset_categories WHERE preset_id = ? ORDER BY position
+`
+
 	SQLAddScoreToRoomPlayer = `UPDATE room_players SET score = score + ? WHERE room_id = ? AND user_id = ?`
 	SQLDeleteRoomPlayer     = `DELETE FROM room_players WHERE room_id = ? AND user_id = ?`
+
+	// Ready-check
+	SQLSetRoomPlayerReady      = `UPDATE room_players SET is_ready = ? WHERE room_id = ? AND user_id = ?`
+	SQLResetRoomPlayersReady   = `UPDATE room_players SET is_ready = 0 WHERE room_id = ?`
+	SQLUpdateRoomReadySettings = `UPDATE rooms SET require_ready = ?, ready_quorum = ?, auto_start = ? WHERE id = ?`
+)
+
+// Historique des parties
+const (
+	SQLInsertGame        = `INSERT INTO games (room_id, type, genre, started_at, settings) VALUES (?, ?, ?, ?, ?)`
+	SQLFinishGame        = `UPDATE games SET ended_at = ? WHERE id = ?`
+	SQLAbandonGame       = `UPDATE games SET ended_at = ?, aborted = 1 WHERE id = ? AND ended_at IS NULL`
+	SQLInsertGamePlayers = `
+        INSERT INTO game_players (game_id, user_id, score)
+        SELECT gr.game_id, rr.user_id, SUM(rr.points)
+        FROM round_results rr
+        JOIN game_rounds gr ON gr.id = rr.round_id
+        WHERE gr.game_id = ?
+        GROUP BY gr.game_id, rr.user_id
+    `
+	SQLRankGamePlayers = `
+        UPDATE game_players
+        SET rank = 1 + (
+            SELECT COUNT(*) FROM game_players gp
+            WHERE gp.game_id = game_players.game_id AND gp.score > game_players.score
+        )
+        WHERE game_id = ?
+    `
+	SQLInsertGameRound = `
+        INSERT INTO game_rounds (game_id, round, subject, details, ended_at)
+        VALUES (?, ?, ?, ?, ?)
+    `
+	SQLInsertRoundResult = `
+        INSERT INTO round_results (round_id, user_id, answer, votes, points)
+        VALUES (?, ?, ?, ?, ?)
+    `
+	SQLListGamesByRoomID = `
+        SELECT id, type, started_at, ended_at, aborted, settings
+        FROM games
+        WHERE room_id = ?
+        ORDER BY started_at DESC, id DESC
+        LIMIT ?
+    `
+	SQLListGameRoundsByGameID = `
+        SELECT id, round, subject, details, ended_at
+        FROM game_rounds
+        WHERE game_id = ?
+        ORDER BY round ASC
+    `
+	SQLListRoundResultsByGameID = `
+        SELECT rr.round_id, rr.user_id, COALESCE(u.pseudo, ''), rr.answer, rr.votes, rr.points
+        FROM round_results rr
+        JOIN game_rounds gr ON gr.id = rr.round_id
+        LEFT JOIN users u ON u.id = rr.user_id
+        WHERE gr.game_id = ?
+        ORDER BY gr.round ASC, rr.points DESC, u.pseudo ASC
+    `
+)
+
+// Profils joueurs
+const (
+	SQLSelectUserByPseudo = `SELECT id, pseudo FROM users WHERE pseudo = ?`
+
+	// parties terminées du joueur, de la plus ancienne à la plus récente
+	SQLListUserFinishedGames = `
+        SELECT g.id, g.type, g.ended_at, g.genre, gp.score, gp.rank,
+               (SELECT COUNT(*) FROM game_players o WHERE o.game_id = g.id)
+        FROM game_players gp
+        JOIN games g ON g.id = gp.game_id
+        WHERE gp.user_id = ? AND g.ended_at IS NOT NULL
+        ORDER BY g.ended_at ASC, g.id ASC
+    `
+	// réponses du joueur manche par manche dans ses parties menées à terme (temps de réponse, taux de validation)
+	SQLListUserRoundResults = `
+        SELECT g.type, rr.answer
+        FROM round_results rr
+        JOIN game_rounds gr ON gr.id = rr.round_id
+        JOIN games g ON g.id = gr.game_id
+        WHERE rr.user_id = ? AND g.ended_at IS NOT NULL AND g.aborted = 0
+        ORDER BY g.id ASC, gr.round ASC
+    `
+)
+
+// Niveau Elo
+const (
+	SQLSelectGameType           = `SELECT type FROM games WHERE id = ?`
+	SQLListGamePlayersPlacement = `SELECT user_id, rank FROM game_players WHERE game_id = ?`
+	SQLSelectPlayerRating       = `SELECT rating, games FROM player_ratings WHERE user_id = ? AND game_type = ?`
+	SQLUpsertPlayerRating       = `
+        INSERT INTO player_ratings (user_id, game_type, rating, games, updated_at)
+        VALUES (?, ?, ?, 1, ?)
+        ON CONFLICT(user_id, game_type) DO UPDATE SET
+            rating = excluded.rating,
+            games = player_ratings.games + 1,
+            updated_at = excluded.updated_at
+    `
+	SQLInsertRatingHistory = `
+        INSERT INTO rating_history (game_id, user_id, game_type, rating_before, rating_after, created_at)
+        VALUES (?, ?, ?, ?, ?, ?)
+    `
+	SQLListRatingHistoryByGameID = `SELECT user_id, rating_before, rating_after FROM rating_history WHERE game_id = ?`
+	SQLListUserRatings           = `SELECT game_type, rating, games FROM player_ratings WHERE user_id = ? ORDER BY game_type ASC`
+)
+
+// Classements (table leaderboard_stats, une ligne par joueur, période, jeu et genre)
+const (
+	SQLLeaderboardSelect = `
+        SELECT s.user_id, u.pseudo, s.points, s.games, s.wins
+        FROM leaderboard_stats s
+        JOIN users u ON u.id = s.user_id
+        WHERE s.period_start = ? AND s.type = ? AND s.genre = ?
+        ORDER BY s.points DESC, s.wins DESC, u.pseudo ASC
+        LIMIT ? OFFSET ?
+    `
+	SQLLeaderboardCount = `SELECT COUNT(*) FROM leaderboard_stats WHERE period_start = ? AND type = ? AND genre = ?`
+	// ajoute une partie terminée aux lignes tous jeux, du jeu et du genre, depuis toujours et de la semaine
+	SQLAddGameToLeaderboard = `
+        INSERT INTO leaderboard_stats (user_id, period_start, type, genre, points, games, wins)
+        SELECT gp.user_id,
+               p.period_start,
+               CASE WHEN s.k = 0 THEN '' ELSE g.type END,
+               CASE WHEN s.k = 2 THEN g.genre ELSE '' END,
+               gp.score, 1,
+               CASE WHEN gp.rank = 1 AND gp.score > 0 THEN 1 ELSE 0 END
+        FROM game_players gp
+        JOIN games g ON g.id = gp.game_id
+        JOIN (SELECT 0 AS period_start UNION ALL SELECT ?) p
+        JOIN (SELECT 0 AS k UNION ALL SELECT 1 UNION ALL SELECT 2) s
+        WHERE gp.game_id = ? AND (s.k < 2 OR g.genre <> '')
+        ON CONFLICT(user_id, period_start, type, genre) DO UPDATE SET
+            points = leaderboard_stats.points + excluded.points,
+            games = leaderboard_stats.games + 1,
+            wins = leaderboard_stats.wins + excluded.wins
+    `
+	SQLListPlayedGenres = `SELECT DISTINCT genre FROM games WHERE genre <> '' AND ended_at IS NOT NULL AND aborted = 0 ORDER BY genre ASC`
+)
+
+// Sessions
+const (
+	SQLInsertSession = `
+        INSERT INTO sessions (id, user_id, created_at, last_seen_at, expires_at)
+        VALUES (?, ?, ?, ?, ?)
+    `
+	SQLSelectSessionByID     = `SELECT id, user_id, created_at, last_seen_at, expires_at FROM sessions WHERE id = ?`
+	SQLTouchSession          = `UPDATE sessions SET last_seen_at = ?, expires_at = ? WHERE id = ?`
+	SQLDeleteSession         = `DELETE FROM sessions WHERE id = ?`
+	SQLDeleteExpiredSessions = `DELETE FROM sessions WHERE expires_at <= ?`
+)
+
+// Playlists des joueurs (Blindtest)
+const (
+	SQLListUserPlaylists = `
+        SELECT p.id, p.owner_id, u.pseudo, p.name,
+               (SELECT COUNT(*) FROM playlist_tracks t WHERE t.playlist_id = p.id)
+        FROM playlists p
+        JOIN users u ON u.id = p.owner_id
+        WHERE p.owner_id = ?1
+           OR EXISTS (SELECT 1 FROM playlist_shares s WHERE s.playlist_id = p.id AND s.user_id = ?1)
+        ORDER BY p.owner_id <> ?1, p.name
+    `
+	SQLSelectPlaylistForUser = `
+        SELECT p.id, p.owner_id, u.pseudo, p.name
+        FROM playlists p
+        JOIN users u ON u.id = p.owner_id
+        WHERE p.id = ?1
+          AND (p.owner_id = ?2 OR EXISTS (SELECT 1 FROM playlist_shares s WHERE s.playlist_id = p.id AND s.user_id = ?2))
+    `
+	SQLListPlaylistTracks = `
+        SELECT id, source, source_ref, title, artist, album, alt_titles, alt_artists
+        FROM playlist_tracks WHERE playlist_id = ? ORDER BY position ASC
+    `
+	SQLListPlaylistShares = `
+        SELECT u.pseudo FROM playlist_shares s JOIN users u ON u.id = s.user_id
+        WHERE s.playlist_id = ? ORDER BY u.pseudo
+    `
+	SQLCountUserPlaylists       = `SELECT COUNT(*) FROM playlists WHERE owner_id = ?`
+	SQLPlaylistNameExists       = `SELECT 1 FROM playlists WHERE owner_id = ? AND name = ? AND id <> ?`
+	SQLInsertPlaylist           = `INSERT INTO playlists (owner_id, name, created_at, updated_at) VALUES (?, ?, ?, ?)`
+	SQLRenamePlaylist           = `UPDATE playlists SET name = ?, updated_at = ? WHERE id = ? AND owner_id = ?`
+	SQLTouchPlaylist            = `UPDATE playlists SET updated_at = ? WHERE id = ? AND owner_id = ?`
+	SQLDeletePlaylist           = `DELETE FROM playlists WHERE id = ? AND owner_id = ?`
+	SQLDeletePlaylistTracks     = `DELETE FROM playlist_tracks WHERE playlist_id = ?`
+	SQLDeletePlaylistShares     = `DELETE FROM playlist_shares WHERE playlist_id = ?`
+	SQLCountPlaylistTracks      = `SELECT COUNT(*) FROM playlist_tracks WHERE playlist_id = ?`
+	SQLMaxPlaylistTrackPosition = `SELECT COALESCE(MAX(position), 0) FROM playlist_tracks WHERE playlist_id = ?`
+	SQLInsertPlaylistTrack      = `
+        INSERT INTO playlist_tracks (playlist_id, position, source, source_ref, title, artist, album, alt_titles, alt_artists)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `
+	SQLDeletePlaylistTrack = `DELETE FROM playlist_tracks WHERE id = ? AND playlist_id = ?`
+	SQLInsertPlaylistShare = `INSERT OR IGNORE INTO playlist_shares (playlist_id, user_id) VALUES (?, ?)`
+	SQLDeletePlaylistShare = `DELETE FROM playlist_shares WHERE playlist_id = ? AND user_id = ?`
+)
+
+// Cache des listes de titres Deezer
+const (
+	SQLSelectDeezerTrackCache = `SELECT tracks, fetched_at FROM deezer_track_cache WHERE source_key = ?`
+	SQLUpsertDeezerTrackCache = `
+        INSERT INTO deezer_track_cache (source_key, tracks, track_count, fetched_at) VALUES (?, ?, ?, ?)
+        ON CONFLICT(source_key) DO UPDATE SET tracks = excluded.tracks, track_count = excluded.track_count, fetched_at = excluded.fetched_at
+    `
 )
@@ -0,0 +1,177 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+)
+
+const (
+	defaultRating = 1000.0
+
+	// K : amplitude maximale d'un changement de niveau sur une partie
+	eloK                = 32.0
+	eloProvisionalK     = 48.0 // premières parties : le niveau se stabilise plus vite
+	eloProvisionalGames = 10
+)
+
+// RatingChange : niveau avant/après une partie (arrondi pour l'affichage)
+type RatingChange struct {
+	Before int `json:"before"`
+	After  int `json:"after"`
+	Delta  int `json:"delta"`
+}
+
+func newRatingChange(before, after float64) RatingChange {
+	b, a := int(math.Round(before)), int(math.Round(after))
+	return RatingChange{Before: b, After: a, Delta: a - b}
+}
+
+// PlayerRating : niveau actuel d'un joueur pour un jeu
+type PlayerRating struct {
+	Type   RoomType `json:"type"`
+	Label  string   `json:"label"`
+	Rating int      `json:"rating"`
+	Games  int      `json:"games"`
+}
+
+// eloDeltas applique un Elo multijoueur : la partie compte comme un duel contre chacun des
+// autres joueurs (victoire si mieux classé, nul en cas d'égalité), ramené à une seule partie.
+func eloDeltas(ratings []float64, ranks []int, ks []float64) []float64 {
+	n := len(ratings)
+	deltas := make([]float64, n)
+	if n < 2 {
+		return deltas
+	}
+	for i := 0; i < n; i++ {
+		sum := 0.0
+		for j := 0; j < n; j++ {
+			if i == j {
+				continue
+			}
+			expected := 1 / (1 + math.Pow(10, (ratings[j]-ratings[i])/400))
+			actual := 0.5
+			switch {
+			case ranks[i] < ranks[j]:
+				actual = 1
+			case ranks[i] > ranks[j]:
+				actual = 0
+			}
+			sum += actual - expected
+		}
+		deltas[i] = ks[i] * sum / float64(n-1)
+	}
+	return deltas
+}
+
+// updateRatingsTx met à jour les niveaux des joueurs d'une partie terminée (game_players déjà classé)
+func updateRatingsTx(ctx context.Context, tx *sql.Tx, gameID int64) (map[int]RatingChange, error) {
+	var gameType string
+	if err := tx.QueryRowContext(ctx, SQLSelectGameType, gameID).Scan(&gameType); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, SQLListGamePlayersPlacement, gameID)
+	if err != nil {
+		return nil, err
+	}
+	var userIDs, ranks []int
+	for rows.Next() {
+		var userID, rank int
+		if err := rows.Scan(&userID, &rank); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+		ranks = append(ranks, rank)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// seul dans la partie : rien à comparer
+	if len(userIDs) < 2 {
+		return map[int]RatingChange{}, nil
+	}
+
+	ratings := make([]float64, len(userIDs))
+	ks := make([]float64, len(userIDs))
+	for i, userID := range userIDs {
+		var games int
+		err := tx.QueryRowContext(ctx, SQLSelectPlayerRating, userID, gameType).Scan(&ratings[i], &games)
+		if errors.Is(err, sql.ErrNoRows) {
+			ratings[i], games = defaultRating, 0
+		} else if err != nil {
+			return nil, err
+		}
+		ks[i] = eloK
+		if games < eloProvisionalGames {
+			ks[i] = eloProvisionalK
+		}
+	}
+
+	deltas := eloDeltas(ratings, ranks, ks)
+	now := time.Now().Unix()
+	changes := make(map[int]RatingChange, len(userIDs))
+	for i, userID := range userIDs {
+		after := ratings[i] + deltas[i]
+		if _, err := tx.ExecContext(ctx, SQLUpsertPlayerRating, userID, gameType, after, now); err != nil {
+			return nil, err
+		}
+		if _, err := tx.ExecContext(ctx, SQLInsertRatingHistory, gameID, userID, gameType, ratings[i], after, now); err != nil {
+			return nil, err
+		}
+		changes[userID] = newRatingChange(ratings[i], after)
+	}
+	return changes, nil
+}
+
+// GetGameRatingChanges relit les changements de niveau d'une partie (scoreboard final, rechargement de page)
+func GetGameRatingChanges(ctx context.Context, gameID int64) (map[int]RatingChange, error) {
+	if Rekdb == nil {
+		return nil, ErrDatabaseNotInitialised
+	}
+	rows, err := Rekdb.QueryContext(ctx, SQLListRatingHistoryByGameID, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := map[int]RatingChange{}
+	for rows.Next() {
+		var userID int
+		var before, after float64
+		if err := rows.Scan(&userID, &before, &after); err != nil {
+			return nil, err
+		}
+		changes[userID] = newRatingChange(before, after)
+	}
+	return changes, rows.Err()
+}
+
+// ListUserRatings renvoie les niveaux d'un joueur pour chaque jeu pratiqué
+func ListUserRatings(ctx context.Context, userID int) ([]PlayerRating, error) {
+	if Rekdb == nil {
+		return nil, ErrDatabaseNotInitialised
+	}
+	rows, err := Rekdb.QueryContext(ctx, SQLListUserRatings, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ratings := []PlayerRating{}
+	for rows.Next() {
+		var r PlayerRating
+		var rating float64
+		if err := rows.Scan(&r.Type, &rating, &r.Games); err != nil {
+			return nil, err
+		}
+		r.Label = r.Type.Label()
+		r.Rating = int(math.Round(rating))
+		ratings = append(ratings, r)
+	}
+	return ratings, rows.Err()
+}
@@ -0,0 +1,258 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	readyCountdown    = 10 * time.Second
+	minReadyQuorum    = 1
+	maxReadyQuorum    = 100
+	readyCheckTimeout = 5 * time.Second
+)
+
+var (
+	ErrPlayersNotReady       = errors.New("players not ready")
+	ErrPlaylistNotConfigured = errors.New("playlist non configurée")
+	ErrInvalidReadySettings  = errors.New("invalid ready settings")
+)
+
+// ReadyStatus résume l'état du ready-check d'une salle
+type ReadyStatus struct {
+	Ready  int `json:"ready"`
+	Total  int `json:"total"`
+	Needed int `json:"needed"`
+}
+
+func (s ReadyStatus) QuorumReached() bool {
+	return s.Total > 0 && s.Ready >= s.Needed
+}
+
+func (s ReadyStatus) Everyone() bool {
+	return s.Total > 0 && s.Ready == s.Total
+}
+
+// computeReadyStatus compte les joueurs prêts ; countAdmin considère l'admin prêt (c'est lui qui lance)
+func computeReadyStatus(room *Room, players []RoomPlayer, countAdmin bool) ReadyStatus {
+	st := ReadyStatus{Total: len(players)}
+	for _, p := range players {
+		if p.IsReady || (countAdmin && p.IsAdmin) {
+			st.Ready++
+		}
+	}
+	quorum := room.ReadyQuorum
+	if quorum < minReadyQuorum || quorum > maxReadyQuorum {
+		quorum = maxReadyQuorum
+	}
+	st.Needed = (st.Total*quorum + 99) / 100
+	return st
+}
+
+// CheckStartAllowed vérifie le quorum quand la salle exige un ready-check
+
+func CheckStartAllowed(ctx context.Context, room *Room) (ReadyStatus, error) {
+	players, err := ListRoomPlayers(ctx, room.ID)
+	if err != nil {
+		return ReadyStatus{}, err
+	}
+	st := computeReadyStatus(room, players, true)
+	if room.RequireReady && !st.QuorumReached() {
+		return st, ErrPlayersNotReady
+	}
+	return st, nil
+}
+
+func UpdateRoomReadySettings(ctx context.Context, roomID int, requireReady bool, quorum int, autoStart bool) error {
+	if Rekdb == nil {
+		return ErrDatabaseNotInitialised
+	}
+	if quorum < minReadyQuorum || quorum > maxReadyQuorum {
+		return fmt.Errorf("%w: quorum must be between %d and %d", ErrInvalidReadySettings, minReadyQuorum, maxReadyQuorum)
+	}
+	_, err := Rekdb.ExecContext(ctx, SQLUpdateRoomReadySettings, boolToInt(requireReady), quorum, boolToInt(autoStart), roomID)
+	return err
+}
+
+func ResetPlayersReady(ctx context.Context, roomID int) error {
+	if Rekdb == nil {
+		return ErrDatabaseNotInitialised
+	}
+	_, err := Rekdb.ExecContext(ctx, SQLResetRoomPlayersReady, roomID)
+	return err
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// StartRoomGame lance (ou relance) la partie correspondant au type de la salle
+
+func StartRoomGame(ctx context.Context, room *Room) error {
+	switch room.Type {
+	case RoomTypeBlindTest:
+		settings, ok, err := GetBlindtestSettings(ctx, room.ID)
+		if err != nil {
+			return err
+		}
+		if !ok || settings.Source.Kind == "" {
+			return ErrPlaylistNotConfigured
+		}
+		source, err := TrackSourceFor(settings.Source, room.Rounds)
+		if err != nil {
+			return err
+		}
+		if _, err := StartOrResetBlindtest(ctx, room, source, settings); err != nil {
+			return fmt.Errorf("%s: %w", source.Name(), err)
+		}
+	case RoomTypePetitBac:
+		if _, err := StartOrResetPetitBac(ctx, room); err != nil {
+			return err
+		}
+	default:
+		return ErrInvalidRoomType
+	}
+
+	cancelReadyCountdown(room.ID, false)
+	if err := ResetPlayersReady(ctx, room.ID); err != nil {
+		log.Printf("Reset ready salle %s : %v", room.Code, err)
+	}
+	return nil
+}
+
+// --- Compte à rebours de démarrage automatique ---
+
+var (
+	readyCountdownsMu sync.Mutex
+	readyCountdowns   = map[int]*readyCountdownState{} // roomID -> compte à rebours
+)
+
+type readyCountdownState struct {
+	timer    *time.Timer
+	startsAt time.Time
+}
+
+// ReadyCountdownEndsAt renvoie la fin du compte à rebours en cours (zéro si aucun)
+func ReadyCountdownEndsAt(roomID int) time.Time {
+	readyCountdownsMu.Lock()
+	defer readyCountdownsMu.Unlock()
+	if cd, ok := readyCountdowns[roomID]; ok {
+		return cd.startsAt
+	}
+	return time.Time{}
+}
+
+func cancelReadyCountdown(roomID int, notify bool) {
+	readyCountdownsMu.Lock()
+	cd, ok := readyCountdowns[roomID]
+	if ok {
+		cd.timer.Stop()
+		delete(readyCountdowns, roomID)
+	}
+	readyCountdownsMu.Unlock()
+
+	if ok && notify {
+		publishRoom(roomID, mustJSON(WSMessage{
+			Type:    "ready_countdown_cancelled",
+			Payload: map[string]any{"room_id": roomID},
+		}))
+	}
+}
+
+// CheckReadyState est appelé à chaque changement (prêt, arrivée, départ) :
+// démarre le compte à rebours quand tout le monde est prêt, l'annule sinon.
+func CheckReadyState(roomID int) {
+	ctx, cancel := context.WithTimeout(context.Background(), readyCheckTimeout)
+	defer cancel()
+
+	room, err := GetRoomByID(ctx, roomID)
+	if err != nil {
+		cancelReadyCountdown(roomID, false)
+		return
+	}
+	if !room.AutoStart || roomGameRunning(roomID) {
+		cancelReadyCountdown(roomID, true)
+		return
+	}
+
+	players, err := ListRoomPlayers(ctx, roomID)
+	if err != nil {
+		return
+	}
+	st := computeReadyStatus(room, players, false)
+	if st.Total < minRoomPlayers || !st.Everyone() {
+		cancelReadyCountdown(roomID, true)
+		return
+	}
+
+	readyCountdownsMu.Lock()
+	if _, running := readyCountdowns[roomID]; running {
+		readyCountdownsMu.Unlock()
+		return
+	}
+	cd := &readyCountdownState{startsAt: time.Now().Add(readyCountdown)}
+	cd.timer = time.AfterFunc(readyCountdown, func() {
+		readyCountdownsMu.Lock()
+		if readyCountdowns[roomID] != cd {
+			readyCountdownsMu.Unlock()
+			return
+		}
+		delete(readyCountdowns, roomID)
+		readyCountdownsMu.Unlock()
+		autoStartRoom(roomID)
+	})
+	readyCountdowns[roomID] = cd
+	readyCountdownsMu.Unlock()
+
+	publishRoom(roomID, mustJSON(WSMessage{
+		Type: "ready_countdown",
+		Payload: map[string]any{
+			"room_id":        roomID,
+			"starts_at_unix": cd.startsAt.Unix(),
+		},
+	}))
+}
+
+func autoStartRoom(roomID int) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	room, err := GetRoomByID(ctx, roomID)
+	if err != nil {
+		return
+	}
+	// dernière vérification : quelqu'un a pu partir pendant le compte à rebours
+	players, err := ListRoomPlayers(ctx, roomID)
+	if err != nil || !computeReadyStatus(room, players, false).Everyone() {
+		return
+	}
+	if err := StartRoomGame(ctx, room); err != nil {
+		log.Printf("Démarrage automatique salle %s échoué : %v", room.Code, err)
+		publishRoom(roomID, mustJSON(WSMessage{
+			Type:    "ready_countdown_cancelled",
+			Payload: map[string]any{"room_id": roomID, "error": err.Error()},
+		}))
+	}
+}
+
+// roomGameRunning indique si une manche est en cours dans la salle
+func roomGameRunning(roomID int) bool {
+	if g, ok := GetBlindtestGame(roomID); ok {
+		if p := g.Phase(); p == "playing" || p == "reveal" {
+			return true
+		}
+	}
+	if g, ok := GetPetitBacGame(roomID); ok {
+		if p := g.Phase(); p == "playing" || p == "validation" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	ErrNotRoomAdmin = errors.New("not room admin")
+	ErrCannotKick   = errors.New("cannot kick this player")
+)
+
+// TransferRoomAdmin donne explicitement les droits d'admin à un autre joueur de la salle
+func TransferRoomAdmin(ctx context.Context, roomID, fromUserID, toUserID int) error {
+	if Rekdb == nil {
+		return ErrDatabaseNotInitialised
+	}
+
+	tx, err := Rekdb.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var adminInt int
+	if err := tx.QueryRowContext(ctx, SQLSelectIsAdminInRoom, roomID, fromUserID).Scan(&adminInt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotRoomAdmin
+		}
+		return err
+	}
+	if adminInt != 1 {
+		return ErrNotRoomAdmin
+	}
+	if fromUserID == toUserID {
+		return nil
+	}
+
+	res, err := tx.ExecContext(ctx, SQLSetRoomPlayerAdmin, 1, roomID, toUserID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrPlayerNotFound
+	}
+	if _, err := tx.ExecContext(ctx, SQLSetRoomPlayerAdmin, 0, roomID, fromUserID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// KickRoomPlayer retire un joueur à la demande de l'admin ; ban l'empêche aussi de revenir.
+// L'admin ne peut pas s'exclure lui-même (il utilise "Quitter"). Le retrait et le bannissement
+// se font dans une seule transaction, et seulement si le joueur est bien dans la salle.
+func KickRoomPlayer(ctx context.Context, roomID, adminID, targetID int, ban bool) error {
+	if Rekdb == nil {
+		return ErrDatabaseNotInitialised
+	}
+	tx, err := Rekdb.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var adminInt int
+	if err := tx.QueryRowContext(ctx, SQLSelectIsAdminInRoom, roomID, adminID).Scan(&adminInt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotRoomAdmin
+		}
+		return err
+	}
+	if adminInt != 1 {
+		return ErrNotRoomAdmin
+	}
+	if targetID == adminID {
+		return ErrCannotKick
+	}
+
+	// l'admin reste dans la salle : elle ne peut pas se fermer ici
+	if _, _, err := removeRoomPlayerTx(ctx, tx, roomID, targetID); err != nil {
+		return err
+	}
+	if ban {
+		if _, err := tx.ExecContext(ctx, SQLInsertRoomBan, roomID, targetID, adminID, time.Now().Unix()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// releaseRoom libère tout ce que le serveur garde en mémoire pour une salle fermée :
+// parties (et leurs timers), compte à rebours et hub WebSocket.
+func releaseRoom(roomID int) {
+	cancelReadyCountdown(roomID, false)
+	StopBlindtest(roomID)
+	StopPetitBac(roomID)
+	stopRoomHub(roomID)
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// RoomStatus est l'état du cycle de vie d'une salle (colonne rooms.status)
+type RoomStatus string
+
+const (
+	RoomStatusLobby    RoomStatus = "lobby"
+	RoomStatusStarting RoomStatus = "starting"
+	RoomStatusInGame   RoomStatus = "in_game"
+	RoomStatusFinished RoomStatus = "finished"
+	RoomStatusClosed   RoomStatus = "closed"
+)
+
+var (
+	ErrInvalidRoomTransition = errors.New("invalid room status transition")
+	ErrRoomClosed            = errors.New("room closed")
+)
+
+// roomTransitions liste les changements d'état autorisés
+var roomTransitions = map[RoomStatus][]RoomStatus{
+	RoomStatusLobby:    {RoomStatusStarting, RoomStatusClosed},
+	RoomStatusStarting: {RoomStatusInGame, RoomStatusLobby, RoomStatusFinished, RoomStatusClosed},
+	RoomStatusInGame:   {RoomStatusStarting, RoomStatusFinished, RoomStatusClosed},
+	RoomStatusFinished: {RoomStatusStarting, RoomStatusLobby, RoomStatusClosed},
+	RoomStatusClosed:   {},
+}
+
+func (s RoomStatus) CanTransitionTo(to RoomStatus) bool {
+	for _, allowed := range roomTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Label renvoie le libellé affiché dans la salle
+func (s RoomStatus) Label() string {
+	switch s {
+	case RoomStatusLobby:
+		return "En attente"
+	case RoomStatusStarting:
+		return "Lancement…"
+	case RoomStatusInGame:
+		return "Partie en cours"
+	case RoomStatusFinished:
+		return "Partie terminée"
+	case RoomStatusClosed:
+		return "Fermée"
+	}
+	return string(s)
+}
+
+// AcceptsPlayers : on rejoint comme joueur dans le lobby ou après une partie
+func (s RoomStatus) AcceptsPlayers() bool {
+	return s == RoomStatusLobby || s == RoomStatusFinished
+}
+
+// AcceptsSpectators : pendant une partie, les nouveaux venus regardent jusqu'à la prochaine
+func (s RoomStatus) AcceptsSpectators() bool {
+	return s == RoomStatusStarting || s == RoomStatusInGame
+}
+
+// TransitionRoomStatus valide et applique un changement d'état ; renvoie l'état précédent.
+// La mise à jour est conditionnée à l'état lu pour éviter deux lancements simultanés.
+func TransitionRoomStatus(ctx context.Context, roomID int, to RoomStatus) (RoomStatus, error) {
+	if Rekdb == nil {
+		return "", ErrDatabaseNotInitialised
+	}
+
+	tx, err := Rekdb.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	current, err := transitionRoomStatusTx(ctx, tx, roomID, to)
+	if err != nil || current == to {
+		return current, err
+	}
+	if err := tx.Commit(); err != nil {
+		return current, err
+	}
+	broadcastRoomStatus(roomID, to, current)
+	return current, nil
+}
+
+// transitionRoomStatusTx applique le changement d'état dans tx ; l'appelant diffuse le nouvel
+// état (broadcastRoomStatus) une fois la transaction validée
+func transitionRoomStatusTx(ctx context.Context, tx *sql.Tx, roomID int, to RoomStatus) (RoomStatus, error) {
+	var current RoomStatus
+	err := tx.QueryRowContext(ctx, SQLSelectRoomStatus, roomID).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrRoomNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if current == to {
+		return current, nil
+	}
+	if !current.CanTransitionTo(to) {
+		return current, fmt.Errorf("%w: %s -> %s", ErrInvalidRoomTransition, current, to)
+	}
+
+	res, err := tx.ExecContext(ctx, SQLUpdateRoomStatus, string(to), roomID, string(current))
+	if err != nil {
+		return current, err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return current, fmt.Errorf("%w: %s a changé entre-temps", ErrInvalidRoomTransition, current)
+	}
+	return current, nil
+}
+
+func broadcastRoomStatus(roomID int, to, previous RoomStatus) {
+	publishRoom(roomID, mustJSON(WSMessage{
+		Type:    "room_status",
+		Payload: map[string]any{"room_id": roomID, "status": to, "previous": previous},
+	}))
+}
+
+// beginRoomStart passe la salle en "starting" ; les spectateurs deviennent joueurs pour la nouvelle partie
+func beginRoomStart(ctx context.Context, roomID int) (RoomStatus, error) {
+	prev, err := TransitionRoomStatus(ctx, roomID, RoomStatusStarting)
+	if err != nil {
+		return prev, err
+	}
+	if _, err := Rekdb.ExecContext(ctx, SQLPromoteRoomSpectators, roomID); err != nil {
+		log.Printf("Promotion spectateurs salle %d : %v", roomID, err)
+	}
+	return prev, nil
+}
+
+// abortRoomStart remet l'état précédent quand le lancement échoue (Deezer KO, etc.)
+func abortRoomStart(roomID int, prev RoomStatus) {
+	if prev == "" || prev == RoomStatusStarting {
+		prev = RoomStatusLobby
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := TransitionRoomStatus(ctx, roomID, prev); err != nil {
+		log.Printf("Retour état salle %d : %v", roomID, err)
+	}
+}
+
+func markRoomInGame(roomID int) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := TransitionRoomStatus(ctx, roomID, RoomStatusInGame); err != nil {
+		log.Printf("Passage en jeu salle %d : %v", roomID, err)
+	}
+}
+
+// markRoomFinished est appelé par les jeux à la fin de la dernière manche
+func markRoomFinished(roomID int) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := TransitionRoomStatus(ctx, roomID, RoomStatusFinished); err != nil {
+		log.Printf("Fin de partie salle %d : %v", roomID, err)
+		return
+	}
+	if _, err := Rekdb.ExecContext(ctx, SQLPromoteRoomSpectators, roomID); err != nil {
+		log.Printf("Promotion spectateurs salle %d : %v", roomID, err)
+	}
+}
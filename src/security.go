@@ -1,19 +1,42 @@
 package server
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
-	"strconv"
 	"sync"
+	"time"
+)
+
+const (
+	sessionTTL           = 7 * 24 * time.Hour // durée de vie glissante d'une session
+	sessionRenewAfter    = time.Minute        // on évite d'écrire en base à chaque requête
+	sessionSweepInterval = 10 * time.Minute
 )
 
 var (
-	sessions   = make(map[string]string) // sessionID -> userID
-	sessionsMu sync.RWMutex
+	sessionStore   SessionStore = NewMemorySessionStore()
+	sessionStoreMu sync.RWMutex
 )
 
+// SetSessionStore remplace le stockage des sessions (InitDB branche SQLite, les tests peuvent garder la mémoire)
+
+func SetSessionStore(store SessionStore) {
+	sessionStoreMu.Lock()
+	sessionStore = store
+	sessionStoreMu.Unlock()
+}
+
+func getSessionStore() SessionStore {
+	sessionStoreMu.RLock()
+	defer sessionStoreMu.RUnlock()
+	return sessionStore
+}
+
 // le CreateSession crée une nouvelle session pour un utilisateur donné et retourne l'ID de session qui peut être stocké dans un cookie
 
 func CreateSession(userID int) (string, error) {
@@ -22,62 +45,104 @@ func CreateSession(userID int) (string, error) {
 		return "", err
 	}
 
-	sessionID := hex.EncodeToString(token)
+	now := time.Now()
+	sess := Session{
+		ID:         hex.EncodeToString(token),
+		UserID:     userID,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(sessionTTL),
+	}
+	if err := getSessionStore().Save(context.Background(), sess); err != nil {
+		return "", err
+	}
+
+	return sess.ID, nil
+}
+
+// lookupSession retrouve la session du cookie et refuse les sessions expirées
 
-	sessionsMu.Lock()
-	sessions[sessionID] = strconv.Itoa(userID)
-	sessionsMu.Unlock()
+func lookupSession(r *http.Request) (*Session, error) {
+	cookie, err := r.Cookie("session_id")
+	if err != nil {
+		return nil, fmt.Errorf("session manquante : %w", err)
+	}
 
-	return sessionID, nil
+	sess, err := getSessionStore().Get(r.Context(), cookie.Value)
+	if err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			return nil, fmt.Errorf("session invalide ou expirée")
+		}
+		return nil, err
+	}
+	if sess.Expired(time.Now()) {
+		_ = getSessionStore().Delete(r.Context(), sess.ID)
+		return nil, fmt.Errorf("session invalide ou expirée")
+	}
+	return sess, nil
 }
 
 // le RequireAuth est un focntion qui agit comme un middleware pour protéger les routes qui nécessitent une authentification avant d'y accéder
+// A chaque passage la session est prolongée (expiration glissante)
 
 func RequireAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cookie, err := r.Cookie("session_id")
+		sess, err := lookupSession(r)
 		if err != nil {
 			http.Redirect(w, r, "/connexion", http.StatusSeeOther)
 			return
 		}
 
-		sessionsMu.RLock()
-		userID := sessions[cookie.Value]
-		sessionsMu.RUnlock()
-
-		if userID == "" {
-			http.Redirect(w, r, "/connexion", http.StatusSeeOther)
-			return
+		now := time.Now()
+		if now.Sub(sess.LastSeenAt) >= sessionRenewAfter {
+			if err := getSessionStore().Touch(r.Context(), sess.ID, now, now.Add(sessionTTL)); err != nil {
+				log.Printf("Renouvellement session échoué : %v", err)
+			}
 		}
 		next.ServeHTTP(w, r)
 	})
 }
 
 // Cette fonction permet d’identifier l’utilisateur connecté à partir du cookie de session et de sécuriser l’accès aux fonctionnalités réservées aux utilisateurs authentifiés.
-//ETANT DONNé que les sessions sont stockées en mémoire pour simplifier la gestion et éviter la complexité d’un système externe.
+// Les sessions sont lues depuis le SessionStore (SQLite une fois la base initialisée) pour survivre aux redémarrages du serveur.
 
 func GetSessionUserID(r *http.Request) (int, error) {
-	cookie, err := r.Cookie("session_id")
+	sess, err := lookupSession(r)
 	if err != nil {
-		return 0, fmt.Errorf("session manquante : %w", err)
+		return 0, err
 	}
+	return sess.UserID, nil
+}
 
-	sessionsMu.RLock()
-	userIDStr, ok := sessions[cookie.Value]
-	sessionsMu.RUnlock()
-
-	if !ok || userIDStr == "" {
-		return 0, fmt.Errorf("session invalide ou expirée")
-	}
-	userID, err := strconv.Atoi(userIDStr)
-	if err != nil {
-		return 0, fmt.Errorf("identifiant de session invalide : %w", err)
+func DeleteSession(sessionID string) {
+	if err := getSessionStore().Delete(context.Background(), sessionID); err != nil {
+		log.Printf("Suppression session échouée : %v", err)
 	}
-	return userID, nil
 }
 
-func DeleteSession(sessionID string) {
-	sessionsMu.Lock()
-	delete(sessions, sessionID)
-	sessionsMu.Unlock()
-}
\ No newline at end of file
+// StartSessionSweeper supprime périodiquement les sessions expirées, jusqu'à l'annulation du contexte
+
+func StartSessionSweeper(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = sessionSweepInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				n, err := getSessionStore().DeleteExpired(ctx, time.Now())
+				if err != nil {
+					log.Printf("Nettoyage sessions échoué : %v", err)
+					continue
+				}
+				if n > 0 {
+					log.Printf("%d session(s) expirée(s) supprimée(s).", n)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session représente une session utilisateur avec ses dates de création, de dernière activité et d'expiration
+type Session struct {
+	ID         string
+	UserID     int
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+}
+
+func (s *Session) Expired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
+
+// SessionStore permet de changer le stockage des sessions (SQLite en prod, mémoire pour les tests)
+type SessionStore interface {
+	Save(ctx context.Context, s Session) error
+	Get(ctx context.Context, id string) (*Session, error)
+	Touch(ctx context.Context, id string, lastSeen, expiresAt time.Time) error
+	Delete(ctx context.Context, id string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// --- Stockage en mémoire ---
+
+type MemorySessionStore struct {
+	mu       sync.RWMutex
+	sessions map[string]Session // sessionID -> session
+}
+
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[string]Session)}
+}
+
+func (m *MemorySessionStore) Save(ctx context.Context, s Session) error {
+	m.mu.Lock()
+	m.sessions[s.ID] = s
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *MemorySessionStore) Get(ctx context.Context, id string) (*Session, error) {
+	m.mu.RLock()
+	s, ok := m.sessions[id]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return &s, nil
+}
+
+func (m *MemorySessionStore) Touch(ctx context.Context, id string, lastSeen, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok {
+		return ErrSessionNotFound
+	}
+	s.LastSeenAt = lastSeen
+	s.ExpiresAt = expiresAt
+	m.sessions[id] = s
+	return nil
+}
+
+func (m *MemorySessionStore) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	delete(m.sessions, id)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *MemorySessionStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for id, s := range m.sessions {
+		if s.Expired(now) {
+			delete(m.sessions, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// --- Stockage SQLite ---
+
+type SQLSessionStore struct {
+	db *sql.DB
+}
+
+func NewSQLSessionStore(db *sql.DB) *SQLSessionStore {
+	return &SQLSessionStore{db: db}
+}
+
+func (s *SQLSessionStore) Save(ctx context.Context, sess Session) error {
+	_, err := s.db.ExecContext(ctx, SQLInsertSession,
+		sess.ID, sess.UserID, sess.CreatedAt.Unix(), sess.LastSeenAt.Unix(), sess.ExpiresAt.Unix())
+	return err
+}
+
+func (s *SQLSessionStore) Get(ctx context.Context, id string) (*Session, error) {
+	var sess Session
+	var created, lastSeen, expires int64
+	err := s.db.QueryRowContext(ctx, SQLSelectSessionByID, id).
+		Scan(&sess.ID, &sess.UserID, &created, &lastSeen, &expires)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	sess.CreatedAt = time.Unix(created, 0)
+	sess.LastSeenAt = time.Unix(lastSeen, 0)
+	sess.ExpiresAt = time.Unix(expires, 0)
+	return &sess, nil
+}
+
+func (s *SQLSessionStore) Touch(ctx context.Context, id string, lastSeen, expiresAt time.Time) error {
+	res, err := s.db.ExecContext(ctx, SQLTouchSession, lastSeen.Unix(), expiresAt.Unix(), id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+func (s *SQLSessionStore) Delete(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx, SQLDeleteSession, id)
+	return err
+}
+
+func (s *SQLSessionStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, SQLDeleteExpiredSessions, now.Unix())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
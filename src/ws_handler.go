@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -14,13 +15,45 @@ const (
 	wsWriteWait      = 10 * time.Second
 	wsPongWait       = 60 * time.Second
 	wsPingPeriod     = (wsPongWait * 9) / 10
-	wsMaxMessageSize = 1024
+	wsMaxMessageSize = 8192 // les réponses du Petit Bac dépassent vite 1 Ko
 )
 
 type WSClient struct {
 	conn   *websocket.Conn
 	send   chan []byte
 	roomID int
+	userID int
+	pseudo string
+
+	maxMessageSize int64
+
+	sendMu sync.Mutex
+	closed bool
+}
+
+// trySend envoie sans bloquer ; false si le client est fermé ou trop lent
+func (c *WSClient) trySend(msg []byte) bool {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.closed {
+		return false
+	}
+	select {
+	case c.send <- msg:
+		return true
+	default:
+		return false
+	}
+}
+
+// closeSend ferme le canal d'envoi une seule fois (le hub et le handler peuvent tous deux le demander)
+func (c *WSClient) closeSend() {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if !c.closed {
+		c.closed = true
+		close(c.send)
+	}
 }
 
 var wsUpgrader = websocket.Upgrader{
@@ -68,23 +101,38 @@ func WSRoomHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var pseudo string
+	_ = Rekdb.QueryRowContext(r.Context(), SQLSelectUserPseudoByID, userID).Scan(&pseudo)
+
 	client := &WSClient{
-		conn:   conn,
-		send:   make(chan []byte, 32),
-		roomID: room.ID,
+		conn:           conn,
+		send:           make(chan []byte, 32),
+		roomID:         room.ID,
+		userID:         userID,
+		pseudo:         pseudo,
+		maxMessageSize: wsMaxMessageSize,
 	}
 
 	hub := getRoomHub(room.ID)
-	hub.register <- client
+	if !hub.join(client) {
+		_ = conn.Close()
+		return
+	}
 
+	// le hub a pu fermer client.send entre-temps (salle libérée, joueur exclu)
 	players, _ := ListRoomPlayers(r.Context(), room.ID)
-	client.send <- mustJSON(WSMessage{
+	if !client.trySend(mustJSON(WSMessage{
 		Type: "room_snapshot",
 		Payload: WSRoomSnapshot{
 			Room:    room,
+			Status:  room.Status,
 			Players: players,
 		},
-	})
+	})) {
+		hub.leave(client)
+		_ = conn.Close()
+		return
+	}
 
 	go func() { client.writePump() }()
 	client.readPump(hub)
@@ -92,11 +140,11 @@ func WSRoomHandler(w http.ResponseWriter, r *http.Request) {
 
 func (c *WSClient) readPump(hub *RoomHub) {
 	defer func() {
-		hub.unregister <- c
+		hub.leave(c)
 		_ = c.conn.Close()
 	}()
 
-	c.conn.SetReadLimit(wsMaxMessageSize)
+	c.conn.SetReadLimit(c.maxMessageSize)
 	_ = c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
 	c.conn.SetPongHandler(func(string) error {
 		_ = c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
@@ -104,11 +152,11 @@ func (c *WSClient) readPump(hub *RoomHub) {
 	})
 
 	for {
-		_, _, err := c.conn.ReadMessage()
+		_, data, err := c.conn.ReadMessage()
 		if err != nil {
 			break
 		}
-		// On ignore les messages clients (WS = diffusion serveur)
+		c.handleInbound(data)
 	}
 }
 
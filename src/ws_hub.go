@@ -12,11 +12,14 @@ type RoomHub struct {
 	register   chan *WSClient
 	unregister chan *WSClient
 	broadcast  chan []byte
+	quit       chan struct{}
 
 	mu      sync.Mutex
 	clients map[*WSClient]struct{}
 }
 
+// getRoomHub renvoie le hub d'une salle en le créant au besoin ; seul WSRoomHandler en crée un,
+// les envois passent par publishRoom
 func getRoomHub(roomID int) *RoomHub {
 	roomHubsMu.Lock()
 	defer roomHubsMu.Unlock()
@@ -29,6 +32,7 @@ func getRoomHub(roomID int) *RoomHub {
 		register:   make(chan *WSClient, 16),
 		unregister: make(chan *WSClient, 16),
 		broadcast:  make(chan []byte, 64),
+		quit:       make(chan struct{}),
 		clients:    make(map[*WSClient]struct{}),
 	}
 	roomHubs[roomID] = h
@@ -48,18 +52,25 @@ func (h *RoomHub) run() {
 			h.mu.Lock()
 			if _, ok := h.clients[c]; ok {
 				delete(h.clients, c)
-				close(c.send)
+				c.closeSend()
 			}
 			h.mu.Unlock()
 
+		case <-h.quit:
+			h.mu.Lock()
+			for c := range h.clients {
+				delete(h.clients, c)
+				c.closeSend()
+			}
+			h.mu.Unlock()
+			return
+
 		case msg := <-h.broadcast:
 			h.mu.Lock()
 			for c := range h.clients {
-				select {
-				case c.send <- msg:
-				default:
+				if !c.trySend(msg) {
 					delete(h.clients, c)
-					close(c.send)
+					c.closeSend()
 				}
 			}
 			h.mu.Unlock()
@@ -67,15 +78,103 @@ func (h *RoomHub) run() {
 	}
 }
 
+// join, leave et publish ne bloquent pas après stopRoomHub : le hub ne lit plus ses canaux,
+// le message est alors simplement abandonné (ses clients sont déjà déconnectés).
+// join renvoie false si la salle a été fermée entre-temps.
+func (h *RoomHub) join(c *WSClient) bool {
+	select {
+	case <-h.quit:
+		return false // le canal register, bufferisé, accepterait encore le client
+	default:
+	}
+	select {
+	case h.register <- c:
+		return true
+	case <-h.quit:
+		return false
+	}
+}
+
+func (h *RoomHub) leave(c *WSClient) {
+	select {
+	case h.unregister <- c:
+	case <-h.quit:
+	}
+}
+
+func (h *RoomHub) publish(msg []byte) {
+	select {
+	case h.broadcast <- msg:
+	case <-h.quit:
+	}
+}
+
+// existingRoomHub renvoie le hub d'une salle sans le créer
+func existingRoomHub(roomID int) (*RoomHub, bool) {
+	roomHubsMu.Lock()
+	defer roomHubsMu.Unlock()
+	h, ok := roomHubs[roomID]
+	return h, ok
+}
+
+// publishRoom diffuse un message dans la salle ; sans hub (personne connecté, salle libérée)
+// le message est abandonné plutôt que de recréer un hub que rien n'arrêterait
+func publishRoom(roomID int, msg []byte) {
+	if h, ok := existingRoomHub(roomID); ok {
+		h.publish(msg)
+	}
+}
+
+// stopRoomHub ferme les connexions restantes et libère le hub d'une salle fermée
+func stopRoomHub(roomID int) {
+	roomHubsMu.Lock()
+	h, ok := roomHubs[roomID]
+	delete(roomHubs, roomID)
+	roomHubsMu.Unlock()
+	if ok {
+		close(h.quit)
+	}
+}
+
 func BroadcastRoomUpdated(roomID int) {
-	h := getRoomHub(roomID)
-	h.broadcast <- mustJSON(WSMessage{Type: "room_updated", Payload: map[string]any{"room_id": roomID}})
+	publishRoom(roomID, mustJSON(WSMessage{Type: "room_updated", Payload: map[string]any{"room_id": roomID}}))
+}
+
+func BroadcastAdminChanged(roomID, userID int, pseudo string) {
+	publishRoom(roomID, mustJSON(WSMessage{
+		Type:    "admin_changed",
+		Payload: map[string]any{"room_id": roomID, "user_id": userID, "pseudo": pseudo},
+	}))
+}
+
+// BroadcastPlayerKicked prévient la salle puis coupe les connexions du joueur exclu :
+// il reçoit le même message (avec "you") juste avant la fermeture pour être redirigé.
+func BroadcastPlayerKicked(roomID, userID int, pseudo string, banned bool) {
+	payload := map[string]any{"room_id": roomID, "user_id": userID, "pseudo": pseudo, "banned": banned}
+
+	h, ok := existingRoomHub(roomID)
+	if !ok {
+		return
+	}
+	h.mu.Lock()
+	for c := range h.clients {
+		if c.userID != userID {
+			continue
+		}
+		delete(h.clients, c)
+		c.trySend(mustJSON(WSMessage{Type: "player_kicked", Payload: map[string]any{
+			"room_id": roomID, "user_id": userID, "pseudo": pseudo, "banned": banned, "you": true,
+		}}))
+		c.closeSend()
+	}
+	h.mu.Unlock()
+
+	h.publish(mustJSON(WSMessage{Type: "player_kicked", Payload: payload}))
 }
 
 func BroadcastPlayerLeft(roomID int, pseudo string) {
-	h := getRoomHub(roomID)
-	h.broadcast <- mustJSON(WSMessage{
+	publishRoom(roomID, mustJSON(WSMessage{
 		Type:    "player_left",
 		Payload: map[string]any{"room_id": roomID, "pseudo": pseudo},
-	})
+	}))
 }
@@ -0,0 +1,191 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	wsInboundTimeout = 5 * time.Second
+	wsChatMaxLength  = 300
+)
+
+var errWSBadPayload = errors.New("payload invalide")
+
+// handleInbound décode un message client et l'envoie vers la même logique de jeu que l'API HTTP.
+// Chaque message reçoit un "ack" ou un "error" portant l'id de corrélation du client.
+func (c *WSClient) handleInbound(data []byte) {
+	var in WSInbound
+	if err := json.Unmarshal(data, &in); err != nil || in.Type == "" {
+		c.replyError("", "Message invalide.")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), wsInboundTimeout)
+	defer cancel()
+
+	// le joueur a pu être exclu de la salle depuis la connexion
+	if ok, err := IsUserInRoom(ctx, c.roomID, c.userID); err != nil || !ok {
+		c.replyError(in.ID, "Accès refusé.")
+		return
+	}
+
+	switch in.Type {
+	case WSInGuess, WSInPetitBacAnswers, WSInPetitBacVotes, WSInPetitBacStop:
+		if spectator, err := IsUserSpectatorInRoom(ctx, c.roomID, c.userID); err != nil || spectator {
+			c.replyError(in.ID, "Spectateur : tu joueras à la prochaine partie.")
+			return
+		}
+	}
+
+	var (
+		result any
+		err    error
+	)
+	switch in.Type {
+	case WSInGuess:
+		result, err = c.inboundGuess(ctx, in.Payload)
+	case WSInPetitBacAnswers:
+		result, err = c.inboundPetitBacAnswers(in.Payload)
+	case WSInPetitBacVotes:
+		result, err = c.inboundPetitBacVotes(in.Payload)
+	case WSInPetitBacStop:
+		result, err = c.inboundPetitBacStop(ctx, in.Payload)
+	case WSInReady:
+		result, err = c.inboundReady(ctx, in.Payload)
+	case WSInChat:
+		result, err = c.inboundChat(in.Payload)
+	default:
+		c.replyError(in.ID, "Type de message inconnu.")
+		return
+	}
+
+	if err != nil {
+		if !errors.Is(err, errWSBadPayload) {
+			log.Printf("WS %s (salle %d, user %d) : %v", in.Type, c.roomID, c.userID, err)
+		}
+		c.replyError(in.ID, err.Error())
+		return
+	}
+	c.trySend(mustJSON(WSMessage{Type: "ack", ID: in.ID, Payload: result}))
+}
+
+func (c *WSClient) replyError(id, msg string) {
+	c.trySend(mustJSON(WSMessage{Type: "error", ID: id, Payload: map[string]any{"error": msg}}))
+}
+
+func (c *WSClient) inboundGuess(ctx context.Context, raw json.RawMessage) (any, error) {
+	var body struct {
+		Guess string `json:"guess"`
+	}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return nil, errWSBadPayload
+	}
+	game, ok := GetBlindtestGame(c.roomID)
+	if !ok {
+		return map[string]any{"locked": true}, nil
+	}
+	return game.SubmitGuess(ctx, c.roomID, c.userID, body.Guess)
+}
+
+func (c *WSClient) inboundPetitBacAnswers(raw json.RawMessage) (any, error) {
+	var answers map[int]string
+	if err := json.Unmarshal(raw, &answers); err != nil {
+		return nil, errWSBadPayload
+	}
+	game, ok := GetPetitBacGame(c.roomID)
+	if !ok {
+		return nil, errors.New("Aucune partie en cours.")
+	}
+	changed, err := game.SubmitAnswers(c.userID, answers)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		BroadcastRoomUpdated(c.roomID)
+	}
+	return map[string]string{"status": "ok"}, nil
+}
+
+func (c *WSClient) inboundPetitBacStop(ctx context.Context, raw json.RawMessage) (any, error) {
+	var answers map[int]string
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &answers); err != nil {
+			return nil, errWSBadPayload
+		}
+	}
+	game, ok := GetPetitBacGame(c.roomID)
+	if !ok {
+		return nil, errors.New("Aucune partie en cours.")
+	}
+	endsAt, err := game.Stop(ctx, c.userID, answers)
+	if err != nil {
+		_, msg := petitBacStopError(err)
+		return nil, errors.New(msg)
+	}
+	BroadcastRoomUpdated(c.roomID)
+	return map[string]any{"status": "ok", "ends_at_unix": endsAt.Unix()}, nil
+}
+
+func (c *WSClient) inboundPetitBacVotes(raw json.RawMessage) (any, error) {
+	var votes map[int]map[int]bool
+	if err := json.Unmarshal(raw, &votes); err != nil {
+		return nil, errWSBadPayload
+	}
+	game, ok := GetPetitBacGame(c.roomID)
+	if !ok {
+		return nil, errors.New("Aucune partie en cours.")
+	}
+	if err := game.SubmitVotes(c.userID, votes); err != nil {
+		return nil, err
+	}
+	BroadcastRoomUpdated(c.roomID)
+	return map[string]string{"status": "ok"}, nil
+}
+
+func (c *WSClient) inboundReady(ctx context.Context, raw json.RawMessage) (any, error) {
+	var body struct {
+		Ready bool `json:"ready"`
+	}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return nil, errWSBadPayload
+	}
+	if err := SetPlayerReady(ctx, c.roomID, c.userID, body.Ready); err != nil {
+		return nil, err
+	}
+	CheckReadyState(c.roomID)
+	BroadcastRoomUpdated(c.roomID)
+	return map[string]bool{"ready": body.Ready}, nil
+}
+
+func (c *WSClient) inboundChat(raw json.RawMessage) (any, error) {
+	var body struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return nil, errWSBadPayload
+	}
+	text := strings.TrimSpace(body.Text)
+	if text == "" {
+		return nil, errors.New("Message vide.")
+	}
+	if utf8.RuneCountInString(text) > wsChatMaxLength {
+		text = string([]rune(text)[:wsChatMaxLength])
+	}
+
+	publishRoom(c.roomID, mustJSON(WSMessage{
+		Type: "chat",
+		Payload: map[string]any{
+			"user_id": c.userID,
+			"pseudo":  c.pseudo,
+			"text":    text,
+			"at_unix": time.Now().Unix(),
+		},
+	}))
+	return map[string]string{"status": "ok"}, nil
+}
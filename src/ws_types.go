@@ -1,11 +1,32 @@
 package server
 
+import "encoding/json"
+
 type WSMessage struct {
 	Type    string      `json:"type"`
+	ID      string      `json:"id,omitempty"` // id de corrélation renvoyé dans les ack/error
 	Payload interface{} `json:"payload,omitempty"`
 }
 
 type WSRoomSnapshot struct {
 	Room    *Room        `json:"room"`
+	Status  RoomStatus   `json:"status"`
 	Players []RoomPlayer `json:"players"`
 }
+
+// WSInbound est un message envoyé par le client sur la WebSocket de la salle
+type WSInbound struct {
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Types de messages acceptés depuis le client
+const (
+	WSInGuess           = "guess"
+	WSInPetitBacAnswers = "petitbac_answers"
+	WSInPetitBacVotes   = "petitbac_votes"
+	WSInPetitBacStop    = "petitbac_stop"
+	WSInReady           = "ready"
+	WSInChat            = "chat"
+)
@@ -0,0 +1,46 @@
+// Config Blindtest : recherche de playlists Deezer ; un clic sur un résultat la choisit comme source
+(function () {
+  const searchInput = document.getElementById("playlist_search");
+  const results = document.getElementById("playlistResults");
+  const kindSelect = document.getElementById("source_kind");
+  const refInput = document.getElementById("source_ref");
+  if (!searchInput || !results || !kindSelect || !refInput) return;
+
+  let timer = null;
+
+  function render(playlists) {
+    results.innerHTML = "";
+    if (!playlists.length) {
+      results.textContent = "Aucune playlist trouvée.";
+      return;
+    }
+    playlists.forEach(p => {
+      const btn = document.createElement("button");
+      btn.type = "button";
+      btn.textContent = `${p.title} (${p.nb_tracks} titres${p.user ? " · " + p.user : ""})`;
+      btn.style.display = "block";
+      btn.style.margin = "4px 0";
+      btn.addEventListener("click", () => {
+        kindSelect.value = "playlist";
+        refInput.value = String(p.id);
+        results.textContent = `Playlist choisie : ${p.title} — pense à enregistrer.`;
+      });
+      results.appendChild(btn);
+    });
+  }
+
+  searchInput.addEventListener("input", () => {
+    clearTimeout(timer);
+    const q = searchInput.value.trim();
+    if (!q) {
+      results.innerHTML = "";
+      return;
+    }
+    timer = setTimeout(() => {
+      fetch(`/api/deezer/playlists?q=${encodeURIComponent(q)}`)
+        .then(r => r.ok ? r.json() : r.text().then(t => { throw new Error(t); }))
+        .then(render)
+        .catch(err => { results.textContent = err.message.trim() || "Deezer injoignable."; });
+    }, 300);
+  });
+})();
@@ -0,0 +1,64 @@
+// Config Petit Bac : réordonner les catégories par glisser-déposer, puis enregistrer
+// toute la liste d'un coup (PUT /api/salle/{code}/petitbac/categories)
+(function () {
+  const form = document.getElementById("categoriesForm");
+  const list = document.getElementById("categoriesList");
+  const errorEl = document.getElementById("categoriesError");
+  if (!form || !list) return;
+
+  const roomCode = form.dataset.roomCode;
+  let dragged = null;
+
+  list.addEventListener("dragstart", (ev) => {
+    dragged = ev.target.closest(".category-row");
+    if (!dragged) return;
+    ev.dataTransfer.effectAllowed = "move";
+    dragged.style.opacity = "0.5";
+  });
+
+  list.addEventListener("dragend", () => {
+    if (dragged) dragged.style.opacity = "";
+    dragged = null;
+  });
+
+  list.addEventListener("dragover", (ev) => {
+    if (!dragged) return;
+    ev.preventDefault();
+    const over = ev.target.closest(".category-row");
+    if (!over || over === dragged) return;
+    const rect = over.getBoundingClientRect();
+    const after = ev.clientY > rect.top + rect.height / 2;
+    list.insertBefore(dragged, after ? over.nextSibling : over);
+  });
+
+  list.addEventListener("drop", (ev) => ev.preventDefault());
+
+  // liste dans l'ordre affiché, sans les catégories cochées « Supprimer », plus la nouvelle
+  function collectCategories() {
+    const out = [];
+    list.querySelectorAll(".category-row").forEach(row => {
+      const id = Number(row.dataset.id);
+      if (form.querySelector(`input[name="delete_${id}"]`)?.checked) return;
+      const name = row.querySelector(`input[name="name_${id}"]`)?.value.trim() || "";
+      out.push({ id, name });
+    });
+    const added = form.querySelector('input[name="new_category"]')?.value.trim();
+    if (added) out.push({ id: 0, name: added });
+    return out;
+  }
+
+  form.addEventListener("submit", (ev) => {
+    ev.preventDefault();
+    if (errorEl) errorEl.textContent = "";
+    fetch(`/api/salle/${encodeURIComponent(roomCode)}/petitbac/categories`, {
+      method: "PUT",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(collectCategories())
+    })
+      .then(r => r.ok ? r.json() : r.text().then(t => { throw new Error(t); }))
+      .then(() => location.reload())
+      .catch(err => {
+        if (errorEl) errorEl.textContent = err.message.trim() || "Erreur lors de l'enregistrement.";
+      });
+  });
+})();
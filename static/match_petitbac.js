@@ -9,8 +9,11 @@ const votesForm = document.getElementById('votesForm');
 const votesDiv = document.getElementById('votes');
 const scoreboard = document.getElementById('scoreboard');
 const scoreList = document.getElementById('scoreList');
+const stopBtn = document.getElementById('stopBtn');
 
 let ws;
+let wsSeq = 0;
+const wsPending = new Map();
 let state = null;
 let debounceTimer = null; 
 
@@ -20,6 +23,7 @@ let localVotes = {};
 let renderedRound = -1;
 let renderedPhase = null;
 let renderedVoteRound = -1; 
+let stopAnnounced = false; // quelqu'un a dit STOP pendant la manche affichée
 
 
 function fetchState() {
@@ -61,11 +65,12 @@ function handlePhaseDisplay() {
     renderedPhase = state.phase;
     
     answersForm.style.display = (state.phase === "playing") ? "" : "none";
+    if (stopBtn) stopBtn.style.display = (state.rules && state.rules.end_mode === "stop") ? "" : "none";
     votesForm.style.display = (state.phase === "validation") ? "" : "none";
     scoreboard.style.display = (state.phase === "finished") ? "" : "none";
 
     if (state.phase === "playing") statusEl.textContent = "À vos claviers !";
-    else if (state.phase === "validation") statusEl.textContent = "Phase de vote";
+    else if (state.phase === "validation") statusEl.textContent = `Phase de vote${voteRuleLabel()}`;
     else if (state.phase === "finished") statusEl.textContent = "Partie terminée !";
     else statusEl.textContent = "En attente...";
 
@@ -76,6 +81,17 @@ function handlePhaseDisplay() {
 }
 
 
+// règle de validation choisie par l'admin de la salle
+function voteRuleLabel() {
+  const rules = state.rules || {};
+  switch (rules.vote_threshold) {
+    case "majority": return " (majorité)";
+    case "unanimous": return " (unanimité)";
+    case "admin": return " (l'admin décide)";
+    default: return " (2/3 des votes)";
+  }
+}
+
 function renderCategoriesOnce() {
 
   if (renderedRound === state.round && categoriesDiv.children.length > 0) {
@@ -84,6 +100,8 @@ function renderCategoriesOnce() {
 
   console.log("Construction des inputs pour la manche " + state.round);
   renderedRound = state.round;
+  stopAnnounced = !!state.stoppedBy;
+  if (stopBtn) stopBtn.disabled = stopAnnounced;
   categoriesDiv.innerHTML = ""; 
   localAnswers = {}; 
 
@@ -124,10 +142,37 @@ function renderCategoriesOnce() {
 
 function triggerAutoSave() {
   if (debounceTimer) clearTimeout(debounceTimer);
+  // après un STOP il ne reste que quelques secondes : on sauvegarde presque tout de suite
   debounceTimer = setTimeout(() => {
     console.log("Auto-save...");
     sendAnswers();
-  }, 2000); 
+  }, stopAnnounced ? 300 : 2000); 
+}
+
+function collectAnswers() {
+  const data = {};
+  state.categories.forEach(cat => {
+      data[cat.ID] = localAnswers[cat.ID] || "";
+  });
+  return data;
+}
+
+function onStopAnnounced(payload) {
+  stopAnnounced = true;
+  if (stopBtn) stopBtn.disabled = true;
+  const sec = Math.max(0, Math.floor(payload.ends_at_unix - Date.now() / 1000));
+  statusEl.textContent = `🛑 ${payload.pseudo || "Un joueur"} a dit STOP ! Plus que ${sec}s`;
+  if (state && state.phase === "playing") {
+    state.endsAt = payload.ends_at_unix;
+    sendAnswers();
+  }
+}
+
+if (stopBtn) {
+  stopBtn.addEventListener('click', () => {
+    sendOrPost("petitbac_stop", "stop", collectAnswers())
+      .catch(err => { statusEl.textContent = err.message; });
+  });
 }
 
 
@@ -140,8 +185,13 @@ function renderVotesSmart() {
     renderedVoteRound = state.round;
   }
 
+  const rules = state.rules || {};
+  const me = state.players.find(p => p.UserID === state.userID);
+  // on juge ses propres réponses si les votes sur soi comptent, ou si on est l'admin qui décide
+  const judgeSelf = rules.vote_threshold === "admin" ? !!(me && me.IsAdmin) : !!rules.self_vote;
+
   state.players.forEach(player => {
-    if (player.UserID === state.userID) return; 
+    if (player.UserID === state.userID && !judgeSelf) return;
     
     const playerAnswers = state.answers[player.UserID];
     if (!playerAnswers) return;
@@ -163,6 +213,24 @@ function renderVotesSmart() {
       const uniqueID = `vote_${player.UserID}_${cat.ID}`;
       let voteRow = document.getElementById(`row_${uniqueID}`);
 
+      // réponse déjà jugée par le dictionnaire (ou lettre incorrecte) : rien à voter
+      const prejudged = state.prejudged && state.prejudged[cat.ID] ? state.prejudged[cat.ID][player.UserID] : undefined;
+      if (prejudged !== undefined) {
+        if (!voteRow) {
+          voteRow = document.createElement('div');
+          voteRow.className = "form-group";
+          voteRow.id = `row_${uniqueID}`;
+          voteRow.innerHTML = `
+            <label>
+              ${cat.Name} : <strong style="color:#4f8cff">${answerText}</strong>
+              ${prejudged ? "✅ Connue du dictionnaire" : "❌ Mauvaise lettre"}
+            </label>
+          `;
+          fieldset.appendChild(voteRow);
+        }
+        return;
+      }
+
       let currentVal = localVotes[`${player.UserID}__${cat.ID}`];
       
      
@@ -211,17 +279,33 @@ function renderVotesSmart() {
 }
 
 
-function sendAnswers() {
-  const data = {};
-  state.categories.forEach(cat => {
-      data[cat.ID] = localAnswers[cat.ID] || "";
+// Envoi sur la WS avec id de corrélation (réponse "ack"/"error"), sinon repli sur l'API HTTP
+function wsRequest(type, payload) {
+  return new Promise((resolve, reject) => {
+    const id = `${type}-${++wsSeq}`;
+    wsPending.set(id, { resolve, reject });
+    ws.send(JSON.stringify({ type, id, payload }));
+    setTimeout(() => {
+      if (wsPending.delete(id)) reject(new Error("timeout"));
+    }, 5000);
   });
-  
-  fetch(`/api/salle/${roomCode}/petitbac/answers`, {
+}
+
+function sendOrPost(type, path, data) {
+  if (ws && ws.readyState === 1) {
+    return wsRequest(type, data);
+  }
+  return fetch(`/api/salle/${roomCode}/petitbac/${path}`, {
     method: "POST",
     headers: {'Content-Type': 'application/json'},
     body: JSON.stringify(data)
-  }).then(() => console.log("Réponses sync server OK"));
+  }).then(r => r.ok ? r.json() : r.text().then(t => { throw new Error(t); }));
+}
+
+function sendAnswers() {
+  sendOrPost("petitbac_answers", "answers", collectAnswers())
+    .then(() => console.log("Réponses sync server OK"))
+    .catch(err => console.warn("Erreur envoi réponses:", err));
 }
 
 answersForm.onsubmit = function(e) {
@@ -239,13 +323,11 @@ function sendVotes() {
     data[cID][pID] = (val === "1");
   });
 
-  fetch(`/api/salle/${roomCode}/petitbac/votes`, {
-    method: "POST",
-    headers: {'Content-Type': 'application/json'},
-    body: JSON.stringify(data)
-  }).then(() => {
+  sendOrPost("petitbac_votes", "votes", data).then(() => {
       statusEl.textContent = "Votes pris en compte !";
       
+  }).catch(err => {
+      statusEl.textContent = err.message;
   });
 }
 
@@ -265,7 +347,27 @@ function connectWS() {
     fetchState();
   };
   
-  ws.onmessage = () => {
+  ws.onmessage = (ev) => {
+      let msg = null;
+      try { msg = JSON.parse(ev.data); } catch (_) {}
+      if (msg && (msg.type === "ack" || msg.type === "error")) {
+        const p = wsPending.get(msg.id);
+        if (p) {
+          wsPending.delete(msg.id);
+          if (msg.type === "ack") p.resolve(msg.payload);
+          else p.reject(new Error((msg.payload && msg.payload.error) || "erreur"));
+        }
+        return;
+      }
+      if (msg && msg.type === "petitbac_stop" && msg.payload) {
+        onStopAnnounced(msg.payload);
+        return;
+      }
+      if (msg && msg.type === "player_kicked" && msg.payload && msg.payload.you) {
+        alert(msg.payload.banned ? "Tu as été banni de la salle." : "Tu as été exclu de la salle.");
+        location.href = "/salle-initialisation";
+        return;
+      }
       fetchState(); 
   };
   
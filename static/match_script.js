@@ -11,6 +11,8 @@
   const replayBtn = document.getElementById("replayBtn");
   const form = document.getElementById("guessForm");
   const guessInput = document.getElementById("guess");
+  const attemptsEl = document.getElementById("attempts");
+  const feedEl = document.getElementById("feed");
 
   let endsAtUnix = 0;
   let phase = "idle";
@@ -35,6 +37,37 @@
     window.state.phase = phase;
   }
 
+  function showAttempts(left, max) {
+    if (!attemptsEl) return;
+    if (phase !== "playing" || max === undefined) {
+      attemptsEl.textContent = "";
+      return;
+    }
+    attemptsEl.textContent = `Essais restants : ${left}/${max}`;
+  }
+
+  function clearFeed() {
+    if (feedEl) feedEl.innerHTML = "";
+  }
+
+  function pushFeed(text) {
+    if (!feedEl) return;
+    const li = document.createElement("li");
+    li.textContent = text;
+    feedEl.appendChild(li);
+  }
+
+  // détail des bonus d'une réponse (barème de la salle)
+  function describeBreakdown(b) {
+    if (!b) return "";
+    const parts = [];
+    if (b.floor) parts.push(`minimum +${b.floor}`);
+    if (b.first) parts.push(`premier +${b.first}`);
+    if (b.streak) parts.push(`série +${b.streak}`);
+    if (b.penalty) parts.push(`pénalité ${b.penalty}`);
+    return parts.length ? ` (${parts.join(", ")})` : "";
+  }
+
   function startTimerUI() {
     function tick() {
       if (!endsAtUnix) {
@@ -72,6 +105,7 @@
     setPhase(st.phase);
     // Si l'API renvoie l'ID utilisateur, on le stocke pour le surlignage "Moi"
     if (st.userID) window.state.userID = st.userID;
+    window.state.ratings = st.ratings || null;
 
     currentRound = st.round || 0;
     totalRounds = st.total_rounds || 0;
@@ -91,6 +125,7 @@
       revealEl.textContent = "";
       form.style.display = "";
       guessInput.disabled = !!st.already_tried;
+      showAttempts(st.attempts_left, st.max_attempts);
       if (st.preview_url) playPreviewLoop(st.preview_url);
       startTimerUI();
       if (scoreboard) scoreboard.style.display = "none";
@@ -139,11 +174,40 @@
   const proto = (location.protocol === "https:") ? "wss" : "ws";
   const ws = new WebSocket(`${proto}://${location.host}/ws/salle/${encodeURIComponent(code)}`);
 
+  // Requêtes client -> serveur sur la WS : chaque envoi porte un id, le serveur répond "ack" ou "error" avec ce même id
+  let wsSeq = 0;
+  const pending = new Map();
+  function wsRequest(type, payload) {
+    return new Promise((resolve, reject) => {
+      const id = `${type}-${++wsSeq}`;
+      pending.set(id, { resolve, reject });
+      ws.send(JSON.stringify({ type, id, payload }));
+      setTimeout(() => {
+        if (pending.delete(id)) reject(new Error("timeout"));
+      }, 5000);
+    });
+  }
+
   ws.onopen = () => refreshState().catch(() => {});
   ws.onmessage = (ev) => {
     try {
       const msg = JSON.parse(ev.data);
 
+      if (msg.type === "ack" || msg.type === "error") {
+        const p = pending.get(msg.id);
+        if (!p) return;
+        pending.delete(msg.id);
+        if (msg.type === "ack") p.resolve(msg.payload);
+        else p.reject(new Error((msg.payload && msg.payload.error) || "erreur"));
+        return;
+      }
+
+      if (msg.type === "player_kicked" && msg.payload && msg.payload.you) {
+        alert(msg.payload.banned ? "Tu as été banni de la salle." : "Tu as été exclu de la salle.");
+        location.href = "/salle-initialisation";
+        return;
+      }
+
       if (msg.type === "blindtest_round_started") {
         setPhase("playing");
         statusEl.textContent = `Manche ${msg.payload.round}/${msg.payload.total_rounds}`;
@@ -151,18 +215,33 @@
         endsAtUnix = msg.payload.ends_at_unix;
         guessInput.disabled = false;
         guessInput.value = "";
+        clearFeed();
+        if (attemptsEl) attemptsEl.textContent = "";
         if (scoreboard) scoreboard.style.display = "none";
         playPreviewLoop(msg.payload.preview_url);
         startTimerUI();
         return;
       }
 
+      if (msg.type === "blindtest_player_found") {
+        const p = msg.payload || {};
+        if (p.title) pushFeed(`${p.pseudo} a trouvé le titre !`);
+        else if (p.artist) pushFeed(`${p.pseudo} a trouvé l'artiste…`);
+        return;
+      }
+
       if (msg.type === "blindtest_round_reveal") {
         setPhase("reveal");
+        showAttempts();
         audio.pause();
         guessInput.disabled = true;
         statusEl.textContent = "Révélation…";
         revealEl.textContent = `Réponse : ${msg.payload.title} — ${msg.payload.artist}`;
+        clearFeed();
+        (msg.payload.scores || []).forEach((s) => {
+          const streak = s.streak_rounds > 1 ? ` — ${s.streak_rounds} d'affilée` : "";
+          pushFeed(`${s.pseudo} : ${s.total >= 0 ? "+" : ""}${s.total} pts${describeBreakdown(s)}${streak}`);
+        });
         // Afficher les scores à la révélation
         loadAndRenderScoreboard(true);
         return;
@@ -170,6 +249,7 @@
 
       if (msg.type === "blindtest_finished") {
         setPhase("finished");
+        window.state.ratings = (msg.payload && msg.payload.ratings) || null;
         endsAtUnix = 0;
         timerEl.textContent = "";
         audio.pause();
@@ -190,21 +270,49 @@
     e.preventDefault();
     const guess = guessInput.value || "";
 
-    const res = await fetch(api("guess"), {
-      method: "POST",
-      headers: { "Content-Type": "application/json" },
-      body: JSON.stringify({ guess })
-    });
-    const out = await res.json();
+    let out;
+    if (ws.readyState === 1) {
+      try {
+        out = await wsRequest("guess", { guess });
+      } catch (err) {
+        statusEl.textContent = err.message;
+        return;
+      }
+    } else {
+      const res = await fetch(api("guess"), {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ guess })
+      });
+      out = await res.json();
+    }
 
     if (out.locked || out.already_tried) {
       guessInput.disabled = true;
     }
-    if (out.correct) {
-      statusEl.textContent = `Bonne réponse ! +${out.points_awarded} pts`;
+    if (out.locked) return;
+    showAttempts(out.attempts_left, (out.attempts_used || 0) + (out.attempts_left || 0));
+
+    const matched = out.matched || {};
+    const bonus = describeBreakdown(out.breakdown);
+    if (out.duplicate) {
+      statusEl.textContent = "Déjà proposé : essai non décompté.";
+    } else if (matched.title && matched.artist) {
+      statusEl.textContent = `Titre et artiste trouvés ! +${out.points_awarded} pts${bonus}`;
+    } else if (matched.title) {
+      statusEl.textContent = `Bon titre ! +${out.points_awarded} pts${bonus}`;
+    } else if (matched.artist) {
+      statusEl.textContent = `Bon artiste ! +${out.points_awarded} pts${bonus}… il manque le titre`;
+    } else if (out.hint === "close") {
+      statusEl.textContent = "Presque ! Tu chauffes… (essai non décompté)";
+    } else if (out.hint === "wrong") {
+      statusEl.textContent = out.points_awarded < 0 ? `Raté. ${out.points_awarded} pts` : "Raté.";
+    }
+    if (out.already_tried) {
       guessInput.disabled = true;
-    } else if (!out.locked) {
-      statusEl.textContent = "Raté pour cette manche.";
+      if (!(out.found && out.found.title)) statusEl.textContent += " Plus d'essais pour cette manche.";
+    } else {
+      guessInput.value = "";
     }
   });
 
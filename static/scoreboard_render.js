@@ -25,6 +25,15 @@ function renderScoreboard() {
       <div class="score-label">POINTS</div>
     `;
 
+    // Niveau après la partie (envoyé par le serveur à la fin)
+    const rating = state.ratings && state.ratings[player.UserID];
+    if (rating) {
+      const sign = rating.delta > 0 ? "+" : "";
+      const cls = rating.delta > 0 ? "up" : (rating.delta < 0 ? "down" : "");
+      li.insertAdjacentHTML("beforeend",
+        `<div class="score-rating ${cls}">Niveau ${rating.after} (${sign}${rating.delta})</div>`);
+    }
+
     scoreList.appendChild(li);
   });
 }
\ No newline at end of file
@@ -2,12 +2,63 @@
   const code = document.body?.dataset?.roomCode;
   if (!code) return;
 
+  const countdownEl = document.getElementById("countdown");
+  let countdownTimer = null;
+
+  function showCountdown(startsAtUnix) {
+    if (!countdownEl) return;
+    if (countdownTimer) clearInterval(countdownTimer);
+    countdownTimer = null;
+    if (!startsAtUnix) {
+      countdownEl.textContent = "";
+      return;
+    }
+    const tick = () => {
+      const s = Math.max(0, Math.ceil(startsAtUnix - Date.now() / 1000));
+      countdownEl.textContent = `Tout le monde est prêt ! Lancement dans ${s}s…`;
+    };
+    tick();
+    countdownTimer = setInterval(tick, 250);
+  }
+
+  showCountdown(Number(document.body.dataset.countdown || 0));
+
   const proto = (location.protocol === "https:") ? "wss" : "ws";
   const ws = new WebSocket(`${proto}://${location.host}/ws/salle/${encodeURIComponent(code)}`);
 
   ws.onmessage = (ev) => {
     try {
       const msg = JSON.parse(ev.data);
+      if (msg.type === "ready_countdown") {
+        showCountdown(msg.payload.starts_at_unix);
+        return;
+      }
+      if (msg.type === "ready_countdown_cancelled") {
+        showCountdown(0);
+        if (countdownEl && msg.payload && msg.payload.error) {
+          countdownEl.textContent = `Lancement annulé : ${msg.payload.error}`;
+        }
+        return;
+      }
+      if (msg.type === "room_status") {
+        // le passage en "starting"/"in_game" est suivi du démarrage de manche, on recharge pour le reste
+        if (msg.payload.status === "finished" || msg.payload.status === "lobby") {
+          location.reload();
+        }
+        return;
+      }
+      if (msg.type === "player_kicked") {
+        if (msg.payload && msg.payload.you) {
+          alert(msg.payload.banned ? "Tu as été banni de la salle." : "Tu as été exclu de la salle.");
+          location.href = "/salle-initialisation";
+        }
+        return;
+      }
+      if (msg.type === "admin_changed") {
+        // les boutons admin (config, lancement) dépendent de l'admin courant
+        location.reload();
+        return;
+      }
       if (msg.type === "room_updated") {
         // léger rafraîchissement d'UI (animation possible avant reload)
         location.reload();